@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrInvalidJWT is returned for malformed, badly signed, expired or not yet valid tokens
+var ErrInvalidJWT = errors.New("invalid token")
+
+// Claims are the JWT claims read by the API. Role is one of the Role constants,
+// customer tokens also carry the id of the customer.
+type Claims struct {
+	Subject    string      `json:"sub"`
+	Issuer     string      `json:"iss"`
+	Audience   interface{} `json:"aud"`
+	ExpiresAt  int64       `json:"exp"`
+	NotBefore  int64       `json:"nbf"`
+	IssuedAt   int64       `json:"iat"`
+	Role       string      `json:"role"`
+	CustomerID int         `json:"customer_id"`
+}
+
+// JWTVerifier validates HS256 tokens with a shared secret and RS256 tokens with an RSA
+// public key, a token signed with an algorithm without configured key is refused
+type JWTVerifier struct {
+	Secret    []byte
+	PublicKey *rsa.PublicKey
+	Issuer    string // required iss claim, not checked when empty
+	Audience  string // required aud claim, not checked when empty
+	Leeway    time.Duration
+	Now       func() time.Time
+}
+
+// NewJWTVerifierFromEnv configures the verifier from JWT_HS256_SECRET, JWT_RS256_PUBLIC_KEY
+// (path to a PEM public key), JWT_ISSUER and JWT_AUDIENCE. It returns nil when no key is configured.
+func NewJWTVerifierFromEnv() (*JWTVerifier, error) {
+	verifier := &JWTVerifier{
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+		Leeway:   30 * time.Second,
+		Now:      time.Now,
+	}
+	if secret := os.Getenv("JWT_HS256_SECRET"); secret != "" {
+		verifier.Secret = []byte(secret)
+	}
+	if path := os.Getenv("JWT_RS256_PUBLIC_KEY"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if verifier.PublicKey, err = ParseRSAPublicKey(data); err != nil {
+			return nil, err
+		}
+	}
+	if verifier.Secret == nil && verifier.PublicKey == nil {
+		return nil, nil
+	}
+	return verifier, nil
+}
+
+// ParseRSAPublicKey reads a PEM encoded PKIX or PKCS #1 RSA public key
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaKey, nil
+}
+
+// Verify checks the signature and validity of a token and returns its principal
+func (v *JWTVerifier) Verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrInvalidJWT
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, ErrInvalidJWT
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, ErrInvalidJWT
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	digest := sha256.Sum256(signed)
+
+	switch header.Algorithm {
+	case "HS256":
+		if v.Secret == nil {
+			return Principal{}, fmt.Errorf("%w: HS256 is not accepted", ErrInvalidJWT)
+		}
+		mac := hmac.New(sha256.New, v.Secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return Principal{}, fmt.Errorf("%w: bad signature", ErrInvalidJWT)
+		}
+	case "RS256":
+		if v.PublicKey == nil {
+			return Principal{}, fmt.Errorf("%w: RS256 is not accepted", ErrInvalidJWT)
+		}
+		if rsa.VerifyPKCS1v15(v.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+			return Principal{}, fmt.Errorf("%w: bad signature", ErrInvalidJWT)
+		}
+	default:
+		return Principal{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidJWT, header.Algorithm)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, ErrInvalidJWT
+	}
+	now := v.Now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(v.Leeway)) {
+		return Principal{}, fmt.Errorf("%w: expired", ErrInvalidJWT)
+	}
+	if claims.NotBefore != 0 && now.Add(v.Leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return Principal{}, fmt.Errorf("%w: not valid yet", ErrInvalidJWT)
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return Principal{}, fmt.Errorf("%w: wrong issuer", ErrInvalidJWT)
+	}
+	if v.Audience != "" && !hasAudience(claims.Audience, v.Audience) {
+		return Principal{}, fmt.Errorf("%w: wrong audience", ErrInvalidJWT)
+	}
+	if claims.Role == RoleCustomer && claims.CustomerID == 0 {
+		return Principal{}, fmt.Errorf("%w: customer token without customer_id", ErrInvalidJWT)
+	}
+
+	return Principal{Subject: claims.Subject, Role: claims.Role, CustomerID: claims.CustomerID}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// hasAudience checks an aud claim, a string or an array of strings
+func hasAudience(claim interface{}, audience string) bool {
+	switch value := claim.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
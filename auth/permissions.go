@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+// Permission lists the roles allowed on a route. Path is the route pattern as registered on
+// the router, ":name" segments match any value. Admins are allowed on every route, API keys
+// on the routes of their scopes.
+type Permission struct {
+	Method string
+	Path   string
+	Roles  []string // allowed roles, any authenticated caller when empty
+	Scope  string   // scope an API key needs, routes without scope are closed to API keys
+	Public bool     // no authentication needed
+}
+
+// Permissions is a per-route permission table
+type Permissions []Permission
+
+// Lookup returns the permission of a request, false when no route of the table matches
+func (p Permissions) Lookup(method, path string) (Permission, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, permission := range p {
+		if permission.Method == method && matchPattern(strings.Split(strings.Trim(permission.Path, "/"), "/"), segments) {
+			return permission, true
+		}
+	}
+	return Permission{}, false
+}
+
+// Scopes returns the scopes of the table, sorted
+func (p Permissions) Scopes() []string {
+	var scopes []string
+	for _, permission := range p {
+		if permission.Scope != "" && !slices.Contains(scopes, permission.Scope) {
+			scopes = append(scopes, permission.Scope)
+		}
+	}
+	slices.Sort(scopes)
+	return scopes
+}
+
+// Allows reports whether the principal may call the route
+func (p Permission) Allows(principal Principal) bool {
+	if principal.APIKeyID != 0 {
+		return p.Scope != "" && slices.Contains(principal.Scopes, p.Scope)
+	}
+	if principal.Role == RoleAdmin || len(p.Roles) == 0 {
+		return true
+	}
+	for _, role := range p.Roles {
+		if role == principal.Role {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i := range pattern {
+		if !strings.HasPrefix(pattern[i], ":") && pattern[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldPermission restricts who may change a field of a resource through PUT and PATCH, on top of
+// the permission of the route. Admins may change every field, API keys none of the listed ones.
+type FieldPermission struct {
+	Resource string
+	Field    string
+	Roles    []string
+}
+
+// FieldPermissions is a per-field permission table
+type FieldPermissions []FieldPermission
+
+// Allows reports whether the principal may change the field of the resource, fields missing
+// from the table may be changed by whoever may call the route
+func (p FieldPermissions) Allows(principal Principal, resource, field string) bool {
+	for _, permission := range p {
+		if permission.Resource != resource || permission.Field != field {
+			continue
+		}
+		if principal.APIKeyID != 0 {
+			return false
+		}
+		return principal.Role == RoleAdmin || slices.Contains(permission.Roles, principal.Role)
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package auth
+
+import "context"
+
+// Roles of the principals
+const (
+	RoleAdmin     = "admin"
+	RoleStaff     = "staff"
+	RoleCustomer  = "customer"
+	RoleReporting = "reporting"
+)
+
+// Principal is the authenticated caller of a request. API keys have no role, they are
+// limited to their scopes.
+type Principal struct {
+	Subject    string   `json:"subject"`
+	Role       string   `json:"role"`
+	CustomerID int      `json:"customer_id"`
+	APIKeyID   int      `json:"api_key_id"`
+	Scopes     []string `json:"scopes"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal of a request, false for anonymous requests
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// CustomerScope returns the customer a request is limited to, 0 when the caller is not a customer
+func CustomerScope(ctx context.Context) int {
+	if principal, ok := FromContext(ctx); ok && principal.Role == RoleCustomer {
+		return principal.CustomerID
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// WriteCSV writes the tables one after the other, each one preceded by its name
+// and separated from the next one by an empty line
+func WriteCSV(w io.Writer, tables []Table) error {
+	writer := csv.NewWriter(w)
+	for i, table := range tables {
+		if i > 0 {
+			if err := writer.Write([]string{}); err != nil {
+				return err
+			}
+		}
+		if len(tables) > 1 {
+			if err := writer.Write([]string{table.Name}); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(table.Columns); err != nil {
+			return err
+		}
+		for _, row := range table.Rows {
+			record := make([]string, len(row))
+			for j, cell := range row {
+				record[j] = formatCell(cell)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 portrait page in PDF points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+	pdfFontSize   = 9.0
+	pdfLineHeight = 14.0
+)
+
+// pdfDocument lays out text and lines on pages using the standard Helvetica fonts,
+// so no font has to be embedded in the file
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// WritePDF writes a printable summary of the tables, one table after the other,
+// starting new pages as needed
+func WritePDF(w io.Writer, title string, tables []Table) error {
+	doc := &pdfDocument{}
+	doc.newPage()
+	doc.text(pdfMargin, "F2", 16, title)
+	doc.y -= pdfLineHeight
+
+	for _, table := range tables {
+		doc.table(table)
+	}
+	return doc.write(w)
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+	d.y = pdfPageHeight - pdfMargin
+}
+
+func (d *pdfDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// ensure starts a new page when less than height points are left
+func (d *pdfDocument) ensure(height float64) {
+	if d.y-height < pdfMargin {
+		d.newPage()
+	}
+}
+
+func (d *pdfDocument) text(x float64, font string, size float64, s string) {
+	d.ensure(size + 4)
+	d.y -= size + 4
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, pdfEscape(s))
+}
+
+func (d *pdfDocument) rule() {
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, d.y-3, pdfPageWidth-pdfMargin, d.y-3)
+}
+
+func (d *pdfDocument) table(table Table) {
+	widths := columnWidths(table)
+
+	d.ensure(3 * pdfLineHeight)
+	d.y -= pdfLineHeight / 2
+	d.text(pdfMargin, "F2", 12, table.Name)
+	d.y -= 4
+
+	row := func(font string, cells []string) {
+		d.ensure(pdfLineHeight)
+		d.y -= pdfLineHeight
+		x := pdfMargin
+		for i, cell := range cells {
+			fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, pdfFontSize, x, d.y, pdfEscape(fit(cell, widths[i])))
+			x += widths[i]
+		}
+	}
+
+	row("F2", table.Columns)
+	d.rule()
+	for _, values := range table.Rows {
+		cells := make([]string, len(table.Columns))
+		for i := range cells {
+			if i < len(values) {
+				cells[i] = formatCell(values[i])
+			}
+		}
+		row("F1", cells)
+	}
+	d.y -= pdfLineHeight / 2
+}
+
+// columnWidths shares the printable width between the columns according to their longest value
+func columnWidths(table Table) []float64 {
+	lengths := make([]float64, len(table.Columns))
+	total := 0.0
+	for i, column := range table.Columns {
+		lengths[i] = float64(len(column))
+		for _, row := range table.Rows {
+			if i < len(row) {
+				if l := float64(len(formatCell(row[i]))); l > lengths[i] {
+					lengths[i] = l
+				}
+			}
+		}
+		if lengths[i] > 40 {
+			lengths[i] = 40
+		}
+		lengths[i] += 2
+		total += lengths[i]
+	}
+
+	widths := make([]float64, len(lengths))
+	for i, l := range lengths {
+		widths[i] = (pdfPageWidth - 2*pdfMargin) * l / total
+	}
+	return widths
+}
+
+// fit truncates s to the width of its column, Helvetica characters average half the font size
+func fit(s string, width float64) string {
+	max := int(width/(pdfFontSize*0.5)) - 1
+	runes := []rune(s)
+	if max < 1 || len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "..."
+}
+
+// pdfEscape escapes string delimiters and maps the text to the WinAnsi encoding
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func (d *pdfDocument) write(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// objects 1 to 4 are the catalog, the page tree and the two fonts,
+	// then every page is followed by its content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := out.WriteTo(w)
+	return err
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"sort"
+	"strings"
+
+	"bookstore.com/models"
+)
+
+// SalesReportTables breaks a sales report down into a summary, its top selling books
+// and the sales per genre and per author
+func SalesReportTables(report models.SalesReport) []Table {
+	summary := Table{
+		Name:    "Summary",
+		Columns: []string{"Metric", "Value"},
+		Rows: [][]interface{}{
+			{"Generated at", report.Timestamp},
+			{"Total revenue", report.TotalRevenue},
+			{"Total orders", report.TotalOrders},
+		},
+	}
+	if report.Period != "" {
+		summary.Rows = append([][]interface{}{
+			{"Period", report.Period},
+			{"From", report.From},
+			{"To", report.To},
+		}, summary.Rows...)
+	}
+
+	books := Table{
+		Name:    "Top selling books",
+		Columns: []string{"Book ID", "Title", "Author", "Quantity", "Unit price", "Revenue"},
+	}
+	genres := make(map[string]*[2]float64)
+	authors := make(map[string]*[2]float64)
+	for _, sale := range report.TopSellingBooks {
+		revenue := float64(sale.Quantity) * sale.Book.Price
+		author := strings.TrimSpace(sale.Book.Author.FirstName + " " + sale.Book.Author.LastName)
+		books.Rows = append(books.Rows, []interface{}{sale.Book.ID, sale.Book.Title, author, sale.Quantity, sale.Book.Price, revenue})
+
+		for _, genre := range sale.Book.Genres {
+			addSale(genres, genre, sale.Quantity, revenue)
+		}
+		addSale(authors, author, sale.Quantity, revenue)
+	}
+
+	return []Table{
+		summary,
+		books,
+		breakdown("Sales by genre", "Genre", genres),
+		breakdown("Sales by author", "Author", authors),
+	}
+}
+
+// SalesReportHistoryTable lists the time-bucketed reports, one row per period
+func SalesReportHistoryTable(reports []models.SalesReport) Table {
+	table := Table{
+		Name:    "Sales reports",
+		Columns: []string{"Report ID", "Period", "From", "To", "Total revenue", "Total orders"},
+	}
+	for _, report := range reports {
+		table.Rows = append(table.Rows, []interface{}{report.ID, report.Period, report.From, report.To, report.TotalRevenue, report.TotalOrders})
+	}
+	return table
+}
+
+func addSale(totals map[string]*[2]float64, key string, quantity int, revenue float64) {
+	if key == "" {
+		key = "Unknown"
+	}
+	if _, exists := totals[key]; !exists {
+		totals[key] = &[2]float64{}
+	}
+	totals[key][0] += float64(quantity)
+	totals[key][1] += revenue
+}
+
+// breakdown builds a table of quantities and revenues sorted by decreasing revenue
+func breakdown(name, column string, totals map[string]*[2]float64) Table {
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if totals[keys[i]][1] == totals[keys[j]][1] {
+			return keys[i] < keys[j]
+		}
+		return totals[keys[i]][1] > totals[keys[j]][1]
+	})
+
+	table := Table{Name: name, Columns: []string{column, "Quantity", "Revenue"}}
+	for _, key := range keys {
+		table.Rows = append(table.Rows, []interface{}{key, int(totals[key][0]), totals[key][1]})
+	}
+	return table
+}
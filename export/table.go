@@ -0,0 +1,48 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Formats supported by the exporters
+const (
+	JSON = "json"
+	CSV  = "csv"
+	XLSX = "xlsx"
+	PDF  = "pdf"
+)
+
+// ContentTypes maps each export format to its MIME type
+var ContentTypes = map[string]string{
+	JSON: "application/json",
+	CSV:  "text/csv",
+	XLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	PDF:  "application/pdf",
+}
+
+// Table is one breakdown of a report, cells hold strings, numbers or times
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// formatCell renders a cell as text for the CSV and PDF exporters
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04")
+	default:
+		return fmt.Sprint(v)
+	}
+}
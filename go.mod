@@ -3,3 +3,5 @@ module bookstore.com
 go 1.23.4
 
 require github.com/julienschmidt/httprouter v1.3.0
+
+require golang.org/x/crypto v0.40.0
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// APIKeyHandler handles the issuance, rotation and revocation of the API keys.
+type APIKeyHandler struct {
+	APIKeyService *services.APIKeyService
+}
+
+var (
+	APIKeyInstance *APIKeyHandler
+	APIKeyOnce     sync.Once
+)
+
+// NewAPIKeyHandler initializes a singleton instance of APIKeyHandler.
+func NewAPIKeyHandler(APIKeyService *services.APIKeyService) *APIKeyHandler {
+	APIKeyOnce.Do(func() {
+		APIKeyInstance = &APIKeyHandler{APIKeyService: APIKeyService}
+	})
+	return APIKeyInstance
+}
+
+// CreateAPIKey issues a key, the answer is the only place the key is shown.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var key models.APIKey
+	if err := decodeInput(w, r, &key); err != nil {
+		log.Printf("APIKeyHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if err := validateInput(w, key); err != nil {
+		log.Printf("APIKeyHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	issuedKey, err := h.APIKeyService.Issue(key)
+	if err != nil {
+		log.Printf("APIKeyHandler.Create: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(issuedKey); err != nil {
+		log.Printf("APIKeyHandler.Create: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("APIKeyHandler.Create: success, key %d, duration: %v", issuedKey.ID, time.Since(start))
+}
+
+func (h *APIKeyHandler) GetAPIKeyById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("APIKeyHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid API key id")
+		return
+	}
+
+	key, err := h.APIKeyService.GetAPIKey(id)
+	if err != nil {
+		log.Printf("APIKeyHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, key.Version) {
+		log.Printf("APIKeyHandler.GetById: not modified, duration: %v", time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		log.Printf("APIKeyHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("APIKeyHandler.GetById: success, duration: %v", time.Since(start))
+}
+
+func (h *APIKeyHandler) GetAPIKeysByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("APIKeyHandler.Search: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	keys, err := h.APIKeyService.SearchAPIKeys(query)
+	if err != nil {
+		log.Printf("APIKeyHandler.Search: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		log.Printf("APIKeyHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("APIKeyHandler.Search: success, returned %d keys, duration: %v", len(keys), time.Since(start))
+}
+
+// RotateAPIKey issues the replacement of a key, the old key keeps working during the overlap.
+func (h *APIKeyHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("APIKeyHandler.Rotate: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid API key id")
+		return
+	}
+
+	var rotation models.APIKeyRotation
+	if err := json.NewDecoder(r.Body).Decode(&rotation); err != nil && err != io.EOF {
+		log.Printf("APIKeyHandler.Rotate: invalid input error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid input: "+err.Error())
+		return
+	}
+	overlap := services.DefaultAPIKeyOverlap
+	if rotation.Overlap != "" {
+		if overlap, err = time.ParseDuration(rotation.Overlap); err != nil {
+			log.Printf("APIKeyHandler.Rotate: invalid overlap error: %v, duration: %v", err, time.Since(start))
+			writeError(w, http.StatusBadRequest, "invalid_overlap", "invalid overlap: "+err.Error())
+			return
+		}
+	}
+
+	if _, err := h.APIKeyService.GetAPIKey(id); err != nil {
+		log.Printf("APIKeyHandler.Rotate: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	issuedKey, err := h.APIKeyService.Rotate(id, overlap)
+	if err != nil {
+		log.Printf("APIKeyHandler.Rotate: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(issuedKey); err != nil {
+		log.Printf("APIKeyHandler.Rotate: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("APIKeyHandler.Rotate: success, key %d replaced by %d, duration: %v", id, issuedKey.ID, time.Since(start))
+}
+
+// RevokeAPIKey disables a key immediately, the record is kept for the audit.
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("APIKeyHandler.Revoke: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid API key id")
+		return
+	}
+
+	existing, err := h.APIKeyService.GetAPIKey(id)
+	if err != nil {
+		log.Printf("APIKeyHandler.Revoke: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("APIKeyHandler.Revoke: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	if _, err := h.APIKeyService.Revoke(id); err != nil {
+		log.Printf("APIKeyHandler.Revoke: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("APIKeyHandler.Revoke: success, duration: %v", time.Since(start))
+}
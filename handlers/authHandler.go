@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"bookstore.com/auth"
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// AuthHandler handles the customer registration, login, password reset and account requests.
+type AuthHandler struct {
+	AuthService     *services.AuthService
+	CustomerService *services.CustomerService
+	OrderService    *services.OrderService
+}
+
+var (
+	AuthInstance *AuthHandler
+	AuthOnce     sync.Once
+)
+
+// NewAuthHandler initializes a singleton instance of AuthHandler.
+func NewAuthHandler(AuthService *services.AuthService, CustomerService *services.CustomerService, OrderService *services.OrderService) *AuthHandler {
+	AuthOnce.Do(func() {
+		AuthInstance = &AuthHandler{AuthService: AuthService, CustomerService: CustomerService, OrderService: OrderService}
+	})
+	return AuthInstance
+}
+
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var registration models.Registration
+	if err := decodeInput(w, r, &registration); err != nil {
+		log.Printf("AuthHandler.Register: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if err := validateInput(w, registration); err != nil {
+		log.Printf("AuthHandler.Register: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	customer, err := h.AuthService.Register(registration)
+	if err != nil {
+		log.Printf("AuthHandler.Register: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(customer); err != nil {
+		log.Printf("AuthHandler.Register: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("AuthHandler.Register: success, duration: %v", time.Since(start))
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var credentials models.Credentials
+	if err := decodeInput(w, r, &credentials); err != nil {
+		log.Printf("AuthHandler.Login: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	session, err := h.AuthService.Login(credentials)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		log.Printf("AuthHandler.Login: invalid credentials, duration: %v", time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if err != nil {
+		log.Printf("AuthHandler.Login: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(session); err != nil {
+		log.Printf("AuthHandler.Login: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("AuthHandler.Login: success, duration: %v", time.Since(start))
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	if err := h.AuthService.Logout(bearerToken(r)); err != nil {
+		log.Printf("AuthHandler.Logout: service error: %v, duration: %v", err, time.Since(start))
+		writeAuthError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("AuthHandler.Logout: success, duration: %v", time.Since(start))
+}
+
+// RequestPasswordReset sends a reset token to the email, the answer is the same for unknown emails.
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := decodeInput(w, r, &request); err != nil {
+		log.Printf("AuthHandler.RequestPasswordReset: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if err := h.AuthService.RequestPasswordReset(request.Email); err != nil {
+		log.Printf("AuthHandler.RequestPasswordReset: service error: %v, duration: %v", err, time.Since(start))
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	log.Printf("AuthHandler.RequestPasswordReset: done, duration: %v", time.Since(start))
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var reset models.PasswordReset
+	if err := decodeInput(w, r, &reset); err != nil {
+		log.Printf("AuthHandler.ResetPassword: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if err := h.AuthService.ResetPassword(reset); err != nil {
+		log.Printf("AuthHandler.ResetPassword: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("AuthHandler.ResetPassword: success, duration: %v", time.Since(start))
+}
+
+// GetAccount returns the profile of the logged-in customer.
+func (h *AuthHandler) GetAccount(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	customerID := auth.CustomerScope(r.Context())
+	if customerID == 0 {
+		log.Printf("AuthHandler.GetAccount: not logged in, duration: %v", time.Since(start))
+		writeAuthError(w, http.StatusUnauthorized, "not logged in as a customer")
+		return
+	}
+
+	customer, err := h.CustomerService.GetCustomer(customerID)
+	if err != nil {
+		log.Printf("AuthHandler.GetAccount: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, customer.Version) {
+		log.Printf("AuthHandler.GetAccount: not modified, duration: %v", time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(customer); err != nil {
+		log.Printf("AuthHandler.GetAccount: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("AuthHandler.GetAccount: success, duration: %v", time.Since(start))
+}
+
+// UpdateAccount updates the name and address of the logged-in customer, the email is the
+// login of the account and does not change.
+func (h *AuthHandler) UpdateAccount(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	customerID := auth.CustomerScope(r.Context())
+	if customerID == 0 {
+		log.Printf("AuthHandler.UpdateAccount: not logged in, duration: %v", time.Since(start))
+		writeAuthError(w, http.StatusUnauthorized, "not logged in as a customer")
+		return
+	}
+
+	var update models.Customer
+	if err := decodeInput(w, r, &update); err != nil {
+		log.Printf("AuthHandler.UpdateAccount: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	customer, err := h.CustomerService.GetCustomer(customerID)
+	if err != nil {
+		log.Printf("AuthHandler.UpdateAccount: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, customer.Version)
+	if !ok {
+		log.Printf("AuthHandler.UpdateAccount: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+	customer.Name = update.Name
+	customer.Address = update.Address
+	customer.Version = version
+	if err := validateInput(w, customer); err != nil {
+		log.Printf("AuthHandler.UpdateAccount: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	updatedCustomer, err := h.CustomerService.UpdateCustomer(customer)
+	if writeVersionConflict(w, err) {
+		log.Printf("AuthHandler.UpdateAccount: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("AuthHandler.UpdateAccount: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedCustomer.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedCustomer); err != nil {
+		log.Printf("AuthHandler.UpdateAccount: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("AuthHandler.UpdateAccount: success, duration: %v", time.Since(start))
+}
+
+// GetAccountOrders returns the orders of the logged-in customer.
+func (h *AuthHandler) GetAccountOrders(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	customerID := auth.CustomerScope(r.Context())
+	if customerID == 0 {
+		log.Printf("AuthHandler.GetAccountOrders: not logged in, duration: %v", time.Since(start))
+		writeAuthError(w, http.StatusUnauthorized, "not logged in as a customer")
+		return
+	}
+
+	orders, err := h.OrderService.SearchOrders(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		log.Printf("AuthHandler.GetAccountOrders: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	orders = slices.DeleteFunc(orders, func(order models.Order) bool {
+		return order.Customer.ID != customerID
+	})
+	slices.SortFunc(orders, func(a, b models.Order) int {
+		return a.ID - b.ID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(orders); err != nil {
+		log.Printf("AuthHandler.GetAccountOrders: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("AuthHandler.GetAccountOrders: success, returned %d orders, duration: %v", len(orders), time.Since(start))
+}
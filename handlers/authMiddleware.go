@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bookstore.com/auth"
+	"bookstore.com/services"
+)
+
+// AuthMiddleware authenticates the requests, with an API key or a bearer token holding a signed
+// JWT or a customer session token, and checks the caller against the permission table before
+// the router runs.
+type AuthMiddleware struct {
+	Sessions    *services.AuthService
+	JWT         *auth.JWTVerifier // nil when no JWT key is configured
+	APIKeys     *services.APIKeyService
+	Permissions auth.Permissions
+}
+
+func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, authenticated, err := m.authenticate(r)
+		if err != nil {
+			log.Printf("AuthMiddleware: %s %s: authentication error: %v", r.Method, r.URL.Path, err)
+			writeAuthError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		// routes missing from the table are reserved to the admins
+		permission, found := m.Permissions.Lookup(r.Method, r.URL.Path)
+		if !found {
+			permission = auth.Permission{Method: r.Method, Path: r.URL.Path, Roles: []string{auth.RoleAdmin}}
+		}
+		if !permission.Public {
+			if !authenticated {
+				writeAuthError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			if !permission.Allows(principal) {
+				if principal.APIKeyID != 0 {
+					log.Printf("AuthMiddleware: %s %s: scopes %v of %s not allowed", r.Method, r.URL.Path, principal.Scopes, principal.Subject)
+					writeAuthError(w, http.StatusForbidden, "API key scopes do not allow "+r.Method+" "+permission.Path)
+					return
+				}
+				log.Printf("AuthMiddleware: %s %s: role %q of %s not allowed", r.Method, r.URL.Path, principal.Role, principal.Subject)
+				writeAuthError(w, http.StatusForbidden, "role "+strconv.Quote(principal.Role)+" is not allowed on "+r.Method+" "+permission.Path)
+				return
+			}
+		}
+
+		if authenticated {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate resolves the API key or the bearer token of a request, API keys come in the
+// X-API-Key header or as bearer tokens starting with "bk_", JWTs have three dot separated segments
+func (m *AuthMiddleware) authenticate(r *http.Request) (auth.Principal, bool, error) {
+	token := bearerToken(r)
+	if key := r.Header.Get("X-API-Key"); key != "" || strings.HasPrefix(token, services.APIKeyPrefix) {
+		if key == "" {
+			key = token
+		}
+		apiKey, err := m.APIKeys.Authenticate(key)
+		if err != nil {
+			return auth.Principal{}, false, err
+		}
+		return auth.Principal{Subject: "api_key:" + strconv.Itoa(apiKey.ID), APIKeyID: apiKey.ID, Scopes: apiKey.Scopes}, true, nil
+	}
+	if token == "" {
+		return auth.Principal{}, false, nil
+	}
+	if strings.Count(token, ".") == 2 {
+		if m.JWT == nil {
+			return auth.Principal{}, false, auth.ErrInvalidJWT
+		}
+		principal, err := m.JWT.Verify(token)
+		return principal, err == nil, err
+	}
+	customer, err := m.Sessions.Authenticate(token)
+	if err != nil {
+		return auth.Principal{}, false, err
+	}
+	return auth.Principal{Subject: "customer:" + strconv.Itoa(customer.ID), Role: auth.RoleCustomer, CustomerID: customer.ID}, true, nil
+}
+
+// writeAuthError answers 401 and 403 with a JSON body
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, status, "unauthorized", message)
+		return
+	}
+	writeError(w, status, "forbidden", message)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
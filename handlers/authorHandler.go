@@ -33,16 +33,20 @@ func (h *AuthorHandler) CreateAuthor(w http.ResponseWriter, r *http.Request, _ h
 	start := time.Now()
 
 	var author models.Author
-	if err := json.NewDecoder(r.Body).Decode(&author); err != nil {
+	if err := decodeInput(w, r, &author); err != nil {
 		log.Printf("AuthorHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateInput(w, author); err != nil {
+		log.Printf("AuthorHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
 		return
 	}
 
 	createdAuthor, err := h.AuthorService.CreateAuthor(author)
 	if err != nil {
 		log.Printf("AuthorHandler.Create: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -62,14 +66,19 @@ func (h *AuthorHandler) GetAuthorById(w http.ResponseWriter, r *http.Request, ps
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("AuthorHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Author ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid author id")
 		return
 	}
 
 	author, err := h.AuthorService.GetAuthor(id)
 	if err != nil {
 		log.Printf("AuthorHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Author not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, author.Version) {
+		log.Printf("AuthorHandler.GetById: not modified, duration: %v", time.Since(start))
 		return
 	}
 
@@ -94,7 +103,7 @@ func (h *AuthorHandler) GetAuthorsByCriteria(w http.ResponseWriter, r *http.Requ
 	authors, err := h.AuthorService.SearchAuthors(query)
 	if err != nil {
 		log.Printf("AuthorHandler.Search: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -113,25 +122,52 @@ func (h *AuthorHandler) UpdateAuthorById(w http.ResponseWriter, r *http.Request,
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("AuthorHandler.Update: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Author ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid author id")
+		return
+	}
+
+	existing, err := h.AuthorService.GetAuthor(id)
+	if err != nil {
+		log.Printf("AuthorHandler.Update: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("AuthorHandler.Update: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	var author models.Author
-	if err = json.NewDecoder(r.Body).Decode(&author); err != nil {
+	if err = decodeInput(w, r, &author); err != nil {
 		log.Printf("AuthorHandler.Update: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	author.ID = id
+	author.Version = version
+
+	if err := validateInput(w, author); err != nil {
+		log.Printf("AuthorHandler.Update: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if !allowedChanges(w, r, "authors", existing, author) {
+		log.Printf("AuthorHandler.Update: forbidden field change, duration: %v", time.Since(start))
+		return
+	}
 
 	updatedAuthor, err := h.AuthorService.UpdateAuthor(author)
+	if writeVersionConflict(w, err) {
+		log.Printf("AuthorHandler.Update: version conflict, duration: %v", time.Since(start))
+		return
+	}
 	if err != nil {
 		log.Printf("AuthorHandler.Update: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Author not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(updatedAuthor.Version))
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(updatedAuthor); err != nil {
 		log.Printf("AuthorHandler.Update: encoding error: %v, duration: %v", err, time.Since(start))
@@ -141,19 +177,86 @@ func (h *AuthorHandler) UpdateAuthorById(w http.ResponseWriter, r *http.Request,
 	log.Printf("AuthorHandler.Update: success, duration: %v", time.Since(start))
 }
 
+// PatchAuthorById applies a JSON merge patch or a JSON patch to an author.
+func (h *AuthorHandler) PatchAuthorById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("AuthorHandler.Patch: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid author id")
+		return
+	}
+
+	existing, err := h.AuthorService.GetAuthor(id)
+	if err != nil {
+		log.Printf("AuthorHandler.Patch: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("AuthorHandler.Patch: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	author, ok := applyPatch(w, r, "authors", existing)
+	if !ok {
+		log.Printf("AuthorHandler.Patch: invalid patch, duration: %v", time.Since(start))
+		return
+	}
+	author.ID = id
+	author.Version = version
+
+	updatedAuthor, err := h.AuthorService.UpdateAuthor(author)
+	if writeVersionConflict(w, err) {
+		log.Printf("AuthorHandler.Patch: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("AuthorHandler.Patch: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedAuthor.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedAuthor); err != nil {
+		log.Printf("AuthorHandler.Patch: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("AuthorHandler.Patch: success, duration: %v", time.Since(start))
+}
+
 func (h *AuthorHandler) DeleteAuthorById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	start := time.Now()
 
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("AuthorHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Author ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid author id")
+		return
+	}
+
+	existing, err := h.AuthorService.GetAuthor(id)
+	if err != nil {
+		log.Printf("AuthorHandler.Delete: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if writeDeletePlan(w, r, "authors", id) {
+		log.Printf("AuthorHandler.Delete: dry run, duration: %v", time.Since(start))
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("AuthorHandler.Delete: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	if err = h.AuthorService.DeleteAuthor(id); err != nil {
 		log.Printf("AuthorHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Author not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
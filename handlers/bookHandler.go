@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,16 +35,20 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request, _ httpr
 	start := time.Now()
 
 	var book models.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
+	if err := decodeInput(w, r, &book); err != nil {
 		log.Printf("BookHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateInput(w, book); err != nil {
+		log.Printf("BookHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
 		return
 	}
 
 	createdBook, err := h.bookService.CreateBook(book)
 	if err != nil {
 		log.Printf("BookHandler.Create: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -59,22 +65,33 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request, _ httpr
 func (h *BookHandler) GetBookById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	start := time.Now()
 
+	options, ok := parseShape(w, r, models.Book{})
+	if !ok {
+		log.Printf("BookHandler.GetById: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("BookHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book id")
 		return
 	}
 
 	book, err := h.bookService.GetBookByID(id)
 	if err != nil {
 		log.Printf("BookHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Book not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, book.Version) {
+		log.Printf("BookHandler.GetById: not modified, duration: %v", time.Since(start))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(book); err != nil {
+	if err := encodeShaped(w, options, book); err != nil {
 		log.Printf("BookHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
 		return
 	}
@@ -85,6 +102,17 @@ func (h *BookHandler) GetBookById(w http.ResponseWriter, r *http.Request, ps htt
 func (h *BookHandler) GetBooksByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	start := time.Now()
 
+	options, ok := parseShape(w, r, models.Book{})
+	if !ok {
+		log.Printf("BookHandler.Search: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+	facets, ok := parseFacets(w, r)
+	if !ok {
+		log.Printf("BookHandler.Search: invalid facets, duration: %v", time.Since(start))
+		return
+	}
+
 	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
 	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
 		query.Filters = make(map[string]interface{})
@@ -94,12 +122,33 @@ func (h *BookHandler) GetBooksByCriteria(w http.ResponseWriter, r *http.Request,
 	books, err := h.bookService.SearchBooks(query)
 	if err != nil {
 		log.Printf("BookHandler.Search: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
+		return
+	}
+
+	if facets != nil {
+		page := models.BookPage{Total: len(books)}
+		if page.Facets, err = h.bookService.BookFacets(query, facets); err != nil {
+			log.Printf("BookHandler.Search: facets error: %v, duration: %v", err, time.Since(start))
+			writeServiceError(w, err)
+			return
+		}
+		if page.Books, err = options.Apply(books); err != nil {
+			log.Printf("BookHandler.Search: shaping error: %v, duration: %v", err, time.Since(start))
+			writeServiceError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			log.Printf("BookHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+			return
+		}
+		log.Printf("BookHandler.Search: success, returned %d books with facets, duration: %v", len(books), time.Since(start))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(books); err != nil {
+	if err := encodeShaped(w, options, books); err != nil {
 		log.Printf("BookHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
 		return
 	}
@@ -113,25 +162,52 @@ func (h *BookHandler) UpdateBookById(w http.ResponseWriter, r *http.Request, ps
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("BookHandler.Update: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book id")
+		return
+	}
+
+	existing, err := h.bookService.GetBookByID(id)
+	if err != nil {
+		log.Printf("BookHandler.Update: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("BookHandler.Update: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	var book models.Book
-	if err = json.NewDecoder(r.Body).Decode(&book); err != nil {
+	if err = decodeInput(w, r, &book); err != nil {
 		log.Printf("BookHandler.Update: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	book.ID = id
+	book.Version = version
+
+	if err := validateInput(w, book); err != nil {
+		log.Printf("BookHandler.Update: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if !allowedChanges(w, r, "books", existing, book) {
+		log.Printf("BookHandler.Update: forbidden field change, duration: %v", time.Since(start))
+		return
+	}
 
 	updatedBook, err := h.bookService.UpdateBook(book)
+	if writeVersionConflict(w, err) {
+		log.Printf("BookHandler.Update: version conflict, duration: %v", time.Since(start))
+		return
+	}
 	if err != nil {
 		log.Printf("BookHandler.Update: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Book not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(updatedBook.Version))
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(updatedBook); err != nil {
 		log.Printf("BookHandler.Update: encoding error: %v, duration: %v", err, time.Since(start))
@@ -141,22 +217,153 @@ func (h *BookHandler) UpdateBookById(w http.ResponseWriter, r *http.Request, ps
 	log.Printf("BookHandler.Update: success, duration: %v", time.Since(start))
 }
 
+// PatchBookById applies a JSON merge patch or a JSON patch to a book.
+func (h *BookHandler) PatchBookById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("BookHandler.Patch: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book id")
+		return
+	}
+
+	existing, err := h.bookService.GetBookByID(id)
+	if err != nil {
+		log.Printf("BookHandler.Patch: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("BookHandler.Patch: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	book, ok := applyPatch(w, r, "books", existing)
+	if !ok {
+		log.Printf("BookHandler.Patch: invalid patch, duration: %v", time.Since(start))
+		return
+	}
+	book.ID = id
+	book.Version = version
+
+	updatedBook, err := h.bookService.UpdateBook(book)
+	if writeVersionConflict(w, err) {
+		log.Printf("BookHandler.Patch: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("BookHandler.Patch: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedBook.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedBook); err != nil {
+		log.Printf("BookHandler.Patch: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("BookHandler.Patch: success, duration: %v", time.Since(start))
+}
+
 func (h *BookHandler) DeleteBookById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	start := time.Now()
 
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("BookHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book id")
+		return
+	}
+
+	existing, err := h.bookService.GetBookByID(id)
+	if err != nil {
+		log.Printf("BookHandler.Delete: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if writeDeletePlan(w, r, "books", id) {
+		log.Printf("BookHandler.Delete: dry run, duration: %v", time.Since(start))
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("BookHandler.Delete: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	if err = h.bookService.DeleteBook(id); err != nil {
 		log.Printf("BookHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Book not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 	log.Printf("BookHandler.Delete: success, duration: %v", time.Since(start))
 }
+
+// GetAuthorBooks searches the books of an author with the filters, sorting and pagination
+// of GetBooksByCriteria.
+func (h *BookHandler) GetAuthorBooks(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	options, ok := parseShape(w, r, models.Book{})
+	if !ok {
+		log.Printf("BookHandler.AuthorBooks: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("BookHandler.AuthorBooks: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid author id")
+		return
+	}
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("BookHandler.AuthorBooks: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	books, err := h.bookService.AuthorBooks(id, query)
+	if err != nil {
+		log.Printf("BookHandler.AuthorBooks: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := encodeShaped(w, options, books); err != nil {
+		log.Printf("BookHandler.AuthorBooks: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("BookHandler.AuthorBooks: success, returned %d books, duration: %v", len(books), time.Since(start))
+}
+
+// parseFacets reads the facets listed in ?facets=genre,price, all of them for an empty
+// ?facets=, and nil without the parameter. It answers 400 for an unknown facet and returns
+// whether the facets are valid.
+func parseFacets(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	if !r.URL.Query().Has("facets") {
+		return nil, true
+	}
+	facets := []string{}
+	for _, name := range strings.Split(r.URL.Query().Get("facets"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !slices.Contains(services.BookFacetNames, name) {
+			writeError(w, http.StatusBadRequest, "invalid_facets", "unknown facet "+name+", the facets are: "+strings.Join(services.BookFacetNames, ", "))
+			return nil, false
+		}
+		facets = append(facets, name)
+	}
+	if len(facets) == 0 {
+		facets = services.BookFacetNames
+	}
+	return facets, true
+}
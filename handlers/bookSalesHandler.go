@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"bookstore.com/export"
 	"bookstore.com/models"
 	"bookstore.com/services"
 	"github.com/julienschmidt/httprouter"
@@ -35,22 +36,25 @@ func NewBookSaleHandler(BookSaleService *services.BookSaleService) *BookSaleHand
 func (h *BookSaleHandler) CreateBookSale(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 
 	var BookSale models.BookSale
-	err := json.NewDecoder(r.Body).Decode(&BookSale)
+	err := decodeInput(w, r, &BookSale)
 	if err != nil {
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateInput(w, BookSale); err != nil {
 		return
 	}
 
 	createdBookSale, err := h.BookSaleService.CreateBookSale(BookSale)
 	if err != nil {
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(createdBookSale); err != nil {
-		http.Error(w, "Failed to write response: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "internal_error", "the server failed to process the request")
 	}
 }
 
@@ -59,19 +63,23 @@ func (h *BookSaleHandler) GetBookSaleById(w http.ResponseWriter, r *http.Request
 
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
-		http.Error(w, "Invalid BookSale ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book sale id")
 		return
 	}
 
 	BookSale, err := h.BookSaleService.GetBookSale(id)
 	if err != nil {
-		http.Error(w, "BookSale not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, BookSale.Version) {
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(BookSale); err != nil {
-		http.Error(w, "Failed to write response: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "internal_error", "the server failed to process the request")
 	}
 }
 
@@ -92,14 +100,14 @@ func (h *BookSaleHandler) GetBookSalesByCriteria(w http.ResponseWriter, r *http.
 	// Call the service layer to search for BookSales based on criteria
 	BookSales, err := h.BookSaleService.SearchBookSales(query)
 	if err != nil {
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
 	// Respond with the found BookSales
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(BookSales); err != nil {
-		http.Error(w, "Failed to write response: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "internal_error", "the server failed to process the request")
 	}
 }
 
@@ -108,20 +116,37 @@ func (h *BookSaleHandler) DeleteBookSaleById(w http.ResponseWriter, r *http.Requ
 
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
-		http.Error(w, "Invalid BookSale ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book sale id")
+		return
+	}
+
+	existing, err := h.BookSaleService.GetBookSale(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
 		return
 	}
 
 	err = h.BookSaleService.DeleteBookSale(id)
 	if err != nil {
-		http.Error(w, "BookSale not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GenerateReports aggregates all book sales into a report, returned as JSON or exported
+// as CSV, XLSX or PDF according to the ?format= parameter or the Accept header.
 func (h *BookSaleHandler) GenerateReports(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	format, err := negotiateFormat(r)
+	if err != nil {
+		writeError(w, http.StatusNotAcceptable, "not_acceptable", err.Error())
+		return
+	}
+
 	var query = models.SearchCriteria{
 		Filters: make(map[string]interface{}),
 	}
@@ -129,7 +154,7 @@ func (h *BookSaleHandler) GenerateReports(w http.ResponseWriter, r *http.Request
 	// Fetch all book sales data
 	bookSales, err := h.BookSaleService.SearchBookSales(query)
 	if err != nil {
-		http.Error(w, "Error fetching sales data", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "internal_error", "the server failed to process the request")
 		return
 	}
 
@@ -170,11 +195,18 @@ func (h *BookSaleHandler) GenerateReports(w http.ResponseWriter, r *http.Request
 		TopSellingBooks: topSellingBooks,
 	}
 
+	if format != export.JSON {
+		if err := writeExport(w, format, "sales-report-"+report.Timestamp.Format("2006-01-02"), "Sales report", export.SalesReportTables(report)); err != nil {
+			log.Printf("Error exporting report: %v", err)
+		}
+		return
+	}
+
 	// Respond with the report data as JSON
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(report); err != nil {
 		log.Printf("Error encoding report: %v", err)
-		http.Error(w, "Error generating report", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "internal_error", "the server failed to process the request")
 		return
 	}
 }
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
+	"bookstore.com/auth"
 	"bookstore.com/models"
 	"bookstore.com/services"
 	"github.com/julienschmidt/httprouter"
@@ -35,17 +37,21 @@ func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request,
 	start := time.Now()
 
 	var Customer models.Customer
-	err := json.NewDecoder(r.Body).Decode(&Customer)
+	err := decodeInput(w, r, &Customer)
 	if err != nil {
 		log.Printf("CustomerHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateInput(w, Customer); err != nil {
+		log.Printf("CustomerHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
 		return
 	}
 
 	createdCustomer, err := h.CustomerService.CreateCustomer(Customer)
 	if err != nil {
 		log.Printf("CustomerHandler.Create: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -62,22 +68,39 @@ func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request,
 func (h *CustomerHandler) GetCustomerById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	start := time.Now()
 
+	options, ok := parseShape(w, r, models.Customer{})
+	if !ok {
+		log.Printf("CustomerHandler.GetById: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("CustomerHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Customer ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid customer id")
+		return
+	}
+
+	if outOfScope(r, id) {
+		log.Printf("CustomerHandler.GetById: forbidden customer %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
 		return
 	}
 
 	Customer, err := h.CustomerService.GetCustomer(id)
 	if err != nil {
 		log.Printf("CustomerHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Customer not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, Customer.Version) {
+		log.Printf("CustomerHandler.GetById: not modified, duration: %v", time.Since(start))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(Customer); err != nil {
+	if err := encodeShaped(w, options, Customer); err != nil {
 		log.Printf("CustomerHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
 		return
 	}
@@ -88,6 +111,12 @@ func (h *CustomerHandler) GetCustomerById(w http.ResponseWriter, r *http.Request
 func (h *CustomerHandler) GetCustomersByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	start := time.Now()
 
+	options, ok := parseShape(w, r, models.Customer{})
+	if !ok {
+		log.Printf("CustomerHandler.Search: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
 	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
 	err := json.NewDecoder(r.Body).Decode(&query.Filters)
 	if err != nil {
@@ -98,12 +127,17 @@ func (h *CustomerHandler) GetCustomersByCriteria(w http.ResponseWriter, r *http.
 	Customers, err := h.CustomerService.SearchCustomers(query)
 	if err != nil {
 		log.Printf("CustomerHandler.Search: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
+	if scope := auth.CustomerScope(r.Context()); scope != 0 {
+		Customers = slices.DeleteFunc(Customers, func(customer models.Customer) bool {
+			return customer.ID != scope
+		})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(Customers); err != nil {
+	if err := encodeShaped(w, options, Customers); err != nil {
 		log.Printf("CustomerHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
 		return
 	}
@@ -117,26 +151,59 @@ func (h *CustomerHandler) UpdateCustomerById(w http.ResponseWriter, r *http.Requ
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("CustomerHandler.Update: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Customer ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid customer id")
+		return
+	}
+
+	if outOfScope(r, id) {
+		log.Printf("CustomerHandler.Update: forbidden customer %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	existing, err := h.CustomerService.GetCustomer(id)
+	if err != nil {
+		log.Printf("CustomerHandler.Update: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("CustomerHandler.Update: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	var Customer models.Customer
-	err = json.NewDecoder(r.Body).Decode(&Customer)
+	err = decodeInput(w, r, &Customer)
 	if err != nil {
 		log.Printf("CustomerHandler.Update: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	Customer.ID = id
+	Customer.Version = version
+
+	if err := validateInput(w, Customer); err != nil {
+		log.Printf("CustomerHandler.Update: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if !allowedChanges(w, r, "customers", existing, Customer) {
+		log.Printf("CustomerHandler.Update: forbidden field change, duration: %v", time.Since(start))
+		return
+	}
 
 	updatedCustomer, err := h.CustomerService.UpdateCustomer(Customer)
+	if writeVersionConflict(w, err) {
+		log.Printf("CustomerHandler.Update: version conflict, duration: %v", time.Since(start))
+		return
+	}
 	if err != nil {
 		log.Printf("CustomerHandler.Update: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Customer not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(updatedCustomer.Version))
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(updatedCustomer); err != nil {
 		log.Printf("CustomerHandler.Update: encoding error: %v, duration: %v", err, time.Since(start))
@@ -146,20 +213,99 @@ func (h *CustomerHandler) UpdateCustomerById(w http.ResponseWriter, r *http.Requ
 	log.Printf("CustomerHandler.Update: success, duration: %v", time.Since(start))
 }
 
+// PatchCustomerById applies a JSON merge patch or a JSON patch to a customer.
+func (h *CustomerHandler) PatchCustomerById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("CustomerHandler.Patch: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid customer id")
+		return
+	}
+
+	if outOfScope(r, id) {
+		log.Printf("CustomerHandler.Patch: forbidden customer %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	existing, err := h.CustomerService.GetCustomer(id)
+	if err != nil {
+		log.Printf("CustomerHandler.Patch: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("CustomerHandler.Patch: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	Customer, ok := applyPatch(w, r, "customers", existing)
+	if !ok {
+		log.Printf("CustomerHandler.Patch: invalid patch, duration: %v", time.Since(start))
+		return
+	}
+	Customer.ID = id
+	Customer.Version = version
+
+	updatedCustomer, err := h.CustomerService.UpdateCustomer(Customer)
+	if writeVersionConflict(w, err) {
+		log.Printf("CustomerHandler.Patch: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("CustomerHandler.Patch: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedCustomer.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedCustomer); err != nil {
+		log.Printf("CustomerHandler.Patch: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("CustomerHandler.Patch: success, duration: %v", time.Since(start))
+}
+
 func (h *CustomerHandler) DeleteCustomerById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	start := time.Now()
 
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("CustomerHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Customer ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid customer id")
+		return
+	}
+
+	if outOfScope(r, id) {
+		log.Printf("CustomerHandler.Delete: forbidden customer %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	existing, err := h.CustomerService.GetCustomer(id)
+	if err != nil {
+		log.Printf("CustomerHandler.Delete: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if writeDeletePlan(w, r, "customers", id) {
+		log.Printf("CustomerHandler.Delete: dry run, duration: %v", time.Since(start))
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("CustomerHandler.Delete: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	err = h.CustomerService.DeleteCustomer(id)
 	if err != nil {
 		log.Printf("CustomerHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Customer not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"bookstore.com/integrity"
+	"bookstore.com/repositories"
+	"bookstore.com/validation"
+)
+
+// problemTypeBase prefixes the stable code of a problem to build its type URI
+const problemTypeBase = "https://bookstore.com/problems/"
+
+// problem is the RFC 7807 body of every error answer. Type and Code identify the problem
+// with a stable code, Detail explains this occurrence, Violations lists the fields breaking
+// validation rules and Blocking the references preventing a delete.
+type problem struct {
+	Type       string             `json:"type"`
+	Title      string             `json:"title"`
+	Status     int                `json:"status"`
+	Detail     string             `json:"detail,omitempty"`
+	Code       string             `json:"code"`
+	Violations validation.Errors  `json:"violations,omitempty"`
+	Blocking   []integrity.Effect `json:"blocking,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, body problem) {
+	body.Type = problemTypeBase + body.Code
+	body.Title = http.StatusText(body.Status)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(body.Status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeProblem(w, problem{Status: status, Code: code, Detail: message})
+}
+
+// errorStatuses maps the kinds of domain errors to their HTTP status
+var errorStatuses = map[error]int{
+	repositories.ErrNotFound:     http.StatusNotFound,
+	repositories.ErrConflict:     http.StatusConflict,
+	repositories.ErrValidation:   http.StatusUnprocessableEntity,
+	repositories.ErrUnauthorized: http.StatusUnauthorized,
+	repositories.ErrForbidden:    http.StatusForbidden,
+}
+
+// writeServiceError answers the error returned by a service or a store with the status and
+// the code of its kind. Errors of no known kind are unexpected, their details are only logged
+// by the handlers and the client gets a 500.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		writeViolations(w, violations)
+		return
+	}
+	var referenced *integrity.ReferencedError
+	if errors.As(err, &referenced) {
+		writeProblem(w, problem{Status: http.StatusConflict, Code: "referenced", Detail: err.Error(), Blocking: referenced.Plan.Blocking})
+		return
+	}
+	var domainError *repositories.Error
+	if errors.As(err, &domainError) {
+		status := errorStatuses[domainError.Kind]
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		writeError(w, status, domainError.Code, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "internal_error", "the server failed to process the request")
+}
+
+// RouteNotFound answers the requests matching no route
+func RouteNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, "route_not_found", "no route matches "+r.URL.Path)
+}
+
+// MethodNotAllowed answers the requests whose route does not accept the method, the router
+// lists the accepted methods in Allow
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path)
+}
+
+// writeDeletePlan answers what deleting an entity would do instead of deleting it when the
+// DELETE request asks for a dry run with ?dry_run=true, and returns whether it answered
+func writeDeletePlan(w http.ResponseWriter, r *http.Request, resource string, id int) bool {
+	if r.URL.Query().Get("dry_run") != "true" {
+		return false
+	}
+	plan, err := integrity.PlanDelete(resource, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+	return true
+}
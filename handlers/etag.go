@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bookstore.com/repositories"
+)
+
+// RequireIfMatch makes the If-Match header mandatory on PUT, PATCH and DELETE of the
+// versioned entities, writes without it answer 428 Precondition Required
+var RequireIfMatch = true
+
+// etag is the entity tag of a version of an entity
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// notModified sets the ETag of an entity and answers 304 Not Modified when it matches the
+// If-None-Match header of a conditional GET, the caller returns when it reports true
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	tag := etag(version)
+	w.Header().Set("ETag", tag)
+	if matchETag(r.Header.Get("If-None-Match"), tag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// checkIfMatch checks the If-Match header of a write against the current version of an
+// entity. It returns the version the store must still hold when writing, 0 when any version
+// is accepted, or answers 428/412 itself and returns false when the write must not happen.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int) (int, bool) {
+	header := r.Header.Get("If-Match")
+	switch {
+	case header == "" && RequireIfMatch:
+		writeError(w, http.StatusPreconditionRequired, "precondition_required", "If-Match header with the ETag of the entity is required")
+		return 0, false
+	case header == "" || strings.TrimSpace(header) == "*":
+		return 0, true
+	case !matchETag(header, etag(version), false):
+		writePreconditionFailed(w, version)
+		return 0, false
+	}
+	return version, true
+}
+
+// writeVersionConflict answers 412 when the store refused a write because the entity changed
+// after checkIfMatch, it reports whether err was such a conflict
+func writeVersionConflict(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, repositories.ErrVersionConflict) {
+		return false
+	}
+	writeError(w, http.StatusPreconditionFailed, "precondition_failed", err.Error())
+	return true
+}
+
+func writePreconditionFailed(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+	writeError(w, http.StatusPreconditionFailed, "precondition_failed", "the entity was modified, its current ETag is "+etag(version))
+}
+
+// matchETag compares a tag with the list of an If-Match or If-None-Match header, weak
+// comparison ignores the W/ prefix and strong comparison never matches weak tags
+func matchETag(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"bookstore.com/export"
+)
+
+// negotiateFormat picks the export format from the ?format= parameter, then from the Accept header,
+// and falls back to JSON
+func negotiateFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if _, supported := export.ContentTypes[format]; !supported {
+			return "", errors.New("unsupported format: " + format)
+		}
+		return format, nil
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])
+		for format, contentType := range export.ContentTypes {
+			if mediaType == contentType {
+				return format, nil
+			}
+		}
+	}
+	return export.JSON, nil
+}
+
+// writeExport writes the tables as a downloadable file in a non JSON format
+func writeExport(w http.ResponseWriter, format, filename, title string, tables []export.Table) error {
+	w.Header().Set("Content-Type", export.ContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+"."+format+`"`)
+
+	switch format {
+	case export.CSV:
+		return export.WriteCSV(w, tables)
+	case export.XLSX:
+		return export.WriteXLSX(w, tables)
+	case export.PDF:
+		return export.WritePDF(w, title, tables)
+	default:
+		return errors.New("unsupported format: " + format)
+	}
+}
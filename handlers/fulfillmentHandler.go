@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// FulfillmentHandler exposes the pre-orders and backorders waiting for stock.
+type FulfillmentHandler struct {
+	FulfillmentService *services.FulfillmentService
+}
+
+var (
+	FulfillmentInstance *FulfillmentHandler
+	FulfillmentOnce     sync.Once
+)
+
+// NewFulfillmentHandler initializes a singleton instance of FulfillmentHandler.
+func NewFulfillmentHandler(FulfillmentService *services.FulfillmentService) *FulfillmentHandler {
+	FulfillmentOnce.Do(func() {
+		FulfillmentInstance = &FulfillmentHandler{FulfillmentService: FulfillmentService}
+	})
+	return FulfillmentInstance
+}
+
+// GetBackorders lists the orders with preordered or backordered items, filtered by book_id.
+func (h *FulfillmentHandler) GetBackorders(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("FulfillmentHandler.Backorders: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	orders, err := h.FulfillmentService.Backorders(query)
+	if err != nil {
+		log.Printf("FulfillmentHandler.Backorders: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(orders); err != nil {
+		log.Printf("FulfillmentHandler.Backorders: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("FulfillmentHandler.Backorders: success, returned %d orders, duration: %v", len(orders), time.Since(start))
+}
+
+// FulfillBackorders allocates the pending items that can be allocated now.
+func (h *FulfillmentHandler) FulfillBackorders(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	orders, err := h.FulfillmentService.Fulfill(time.Now())
+	if err != nil {
+		log.Printf("FulfillmentHandler.Fulfill: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(orders); err != nil {
+		log.Printf("FulfillmentHandler.Fulfill: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("FulfillmentHandler.Fulfill: success, %d orders received stock, duration: %v", len(orders), time.Since(start))
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bookstore.com/idempotency"
+)
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header
+const maxIdempotencyKeyLen = 255
+
+// IdempotencyMiddleware replays the response of a POST sent again with the same
+// Idempotency-Key header, so a retried request does not create a second entity. Keys are
+// scoped to the client and the route, reusing a key with another body answers 422.
+type IdempotencyMiddleware struct {
+	Store idempotency.Store
+	TTL   time.Duration
+}
+
+func (m *IdempotencyMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			writeError(w, http.StatusBadRequest, "bad_request", "Idempotency-Key must not exceed 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "invalid input: "+err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+		storeKey := clientKey(r) + "|" + r.Method + " " + r.URL.Path + "|" + key
+
+		record, claimed, err := m.Store.Begin(storeKey, fingerprint, time.Now(), m.TTL)
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			writeError(w, http.StatusConflict, "conflict", "a request with this Idempotency-Key is still in progress")
+			return
+		case err != nil:
+			log.Printf("IdempotencyMiddleware: %s %s: store error: %v", r.Method, r.URL.Path, err)
+			writeServiceError(w, err)
+			return
+		case !claimed && record.Fingerprint != fingerprint:
+			writeError(w, http.StatusUnprocessableEntity, "unprocessable_entity", "Idempotency-Key was already used with a different request")
+			return
+		case !claimed:
+			log.Printf("IdempotencyMiddleware: %s %s: replaying key %q", r.Method, r.URL.Path, key)
+			for name, values := range record.Response.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.Response.Status)
+			w.Write(record.Response.Body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// server errors are not recorded so the client can retry
+		response := recorder.response()
+		if response.Status >= http.StatusInternalServerError {
+			err = m.Store.Abort(storeKey)
+		} else {
+			err = m.Store.Complete(storeKey, response)
+		}
+		if err != nil {
+			log.Printf("IdempotencyMiddleware: %s %s: store error: %v", r.Method, r.URL.Path, err)
+		}
+	})
+}
+
+// responseRecorder copies the response it writes for the idempotency store
+type responseRecorder struct {
+	http.ResponseWriter
+	mu          sync.Mutex
+	status      int
+	wroteHeader bool
+	header      http.Header
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		r.status = status
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.body.Write(b)
+	r.mu.Unlock()
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) response() idempotency.Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	header := r.header
+	if header == nil {
+		header = r.ResponseWriter.Header().Clone()
+	}
+	for name := range header {
+		if strings.HasPrefix(name, "Ratelimit") || name == "Retry-After" {
+			delete(header, name)
+		}
+	}
+	return idempotency.Response{Status: r.status, Header: header, Body: bytes.Clone(r.body.Bytes())}
+}
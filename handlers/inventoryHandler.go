@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// InventoryHandler handles the stock ledger and inventory valuation requests.
+type InventoryHandler struct {
+	InventoryService *services.InventoryService
+}
+
+var (
+	InventoryInstance *InventoryHandler
+	InventoryOnce     sync.Once
+)
+
+// NewInventoryHandler initializes a singleton instance of InventoryHandler.
+func NewInventoryHandler(InventoryService *services.InventoryService) *InventoryHandler {
+	InventoryOnce.Do(func() {
+		InventoryInstance = &InventoryHandler{InventoryService: InventoryService}
+	})
+	return InventoryInstance
+}
+
+// CreateMovement records a stock movement (receipt, sale, return, adjustment or damage).
+func (h *InventoryHandler) CreateMovement(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var movement models.StockMovement
+	if err := decodeInput(w, r, &movement); err != nil {
+		log.Printf("InventoryHandler.CreateMovement: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+	movement.CreatedAt = time.Time{}
+
+	createdMovement, err := h.InventoryService.RecordMovement(movement)
+	if err != nil {
+		log.Printf("InventoryHandler.CreateMovement: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdMovement); err != nil {
+		log.Printf("InventoryHandler.CreateMovement: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("InventoryHandler.CreateMovement: success, duration: %v", time.Since(start))
+}
+
+func (h *InventoryHandler) GetMovementById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("InventoryHandler.GetMovementById: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid movement id")
+		return
+	}
+
+	movement, err := h.InventoryService.GetMovement(id)
+	if err != nil {
+		log.Printf("InventoryHandler.GetMovementById: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(movement); err != nil {
+		log.Printf("InventoryHandler.GetMovementById: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("InventoryHandler.GetMovementById: success, duration: %v", time.Since(start))
+}
+
+// GetBookMovements returns the stock ledger of a book, filtered by type, from and to.
+func (h *InventoryHandler) GetBookMovements(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("InventoryHandler.GetBookMovements: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book id")
+		return
+	}
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("InventoryHandler.GetBookMovements: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	movements, err := h.InventoryService.BookMovements(id, query)
+	if err != nil {
+		log.Printf("InventoryHandler.GetBookMovements: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(movements); err != nil {
+		log.Printf("InventoryHandler.GetBookMovements: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("InventoryHandler.GetBookMovements: success, returned %d movements, duration: %v", len(movements), time.Since(start))
+}
+
+// GetValuation values the inventory with ?method=fifo|average, as of ?at= (RFC 3339) or now.
+func (h *InventoryHandler) GetValuation(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var at time.Time
+	if value := r.URL.Query().Get("at"); value != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, value); err != nil {
+			log.Printf("InventoryHandler.GetValuation: invalid date error: %v, duration: %v", err, time.Since(start))
+			writeError(w, http.StatusBadRequest, "invalid_date", "invalid date: "+err.Error())
+			return
+		}
+	}
+
+	valuation, err := h.InventoryService.Valuation(r.URL.Query().Get("method"), at)
+	if err != nil {
+		log.Printf("InventoryHandler.GetValuation: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(valuation); err != nil {
+		log.Printf("InventoryHandler.GetValuation: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("InventoryHandler.GetValuation: success, duration: %v", time.Since(start))
+}
+
+// CreateTransfer moves stock of a book between two warehouses.
+func (h *InventoryHandler) CreateTransfer(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var transfer models.StockTransfer
+	if err := decodeInput(w, r, &transfer); err != nil {
+		log.Printf("InventoryHandler.CreateTransfer: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	createdTransfer, err := h.InventoryService.Transfer(transfer)
+	if err != nil {
+		log.Printf("InventoryHandler.CreateTransfer: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdTransfer); err != nil {
+		log.Printf("InventoryHandler.CreateTransfer: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("InventoryHandler.CreateTransfer: success, duration: %v", time.Since(start))
+}
+
+// GetBookStockLevels returns the stock of a book per warehouse.
+func (h *InventoryHandler) GetBookStockLevels(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("InventoryHandler.GetBookStockLevels: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid book id")
+		return
+	}
+
+	levels, err := h.InventoryService.StockLevels(id)
+	if err != nil {
+		log.Printf("InventoryHandler.GetBookStockLevels: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(levels); err != nil {
+		log.Printf("InventoryHandler.GetBookStockLevels: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("InventoryHandler.GetBookStockLevels: success, duration: %v", time.Since(start))
+}
+
+// GetWarehouseStock returns the stock levels of a warehouse.
+func (h *InventoryHandler) GetWarehouseStock(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("InventoryHandler.GetWarehouseStock: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid warehouse id")
+		return
+	}
+
+	levels, err := h.InventoryService.WarehouseStock(id)
+	if err != nil {
+		log.Printf("InventoryHandler.GetWarehouseStock: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(levels); err != nil {
+		log.Printf("InventoryHandler.GetWarehouseStock: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("InventoryHandler.GetWarehouseStock: success, duration: %v", time.Since(start))
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
+	"bookstore.com/auth"
 	"bookstore.com/models"
 	"bookstore.com/services"
 	"github.com/julienschmidt/httprouter"
@@ -35,17 +37,24 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request, _ htt
 	start := time.Now()
 
 	var Order models.Order
-	err := json.NewDecoder(r.Body).Decode(&Order)
+	err := decodeInput(w, r, &Order)
 	if err != nil {
 		log.Printf("OrderHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if scope := auth.CustomerScope(r.Context()); scope != 0 {
+		Order.Customer = models.Customer{ID: scope}
+	}
+
+	if err := validateInput(w, Order); err != nil {
+		log.Printf("OrderHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
 		return
 	}
 
 	createdOrder, err := h.OrderService.CreateOrder(Order)
 	if err != nil {
 		log.Printf("OrderHandler.Create: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -62,22 +71,38 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request, _ htt
 func (h *OrderHandler) GetOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	start := time.Now()
 
+	options, ok := parseShape(w, r, models.Order{})
+	if !ok {
+		log.Printf("OrderHandler.GetById: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("OrderHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Order ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
 		return
 	}
 
 	Order, err := h.OrderService.GetOrder(id)
 	if err != nil {
 		log.Printf("OrderHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Order not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
+		return
+	}
+	if outOfScope(r, Order.Customer.ID) {
+		log.Printf("OrderHandler.GetById: forbidden order %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	if notModified(w, r, Order.Version) {
+		log.Printf("OrderHandler.GetById: not modified, duration: %v", time.Since(start))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(Order); err != nil {
+	if err := encodeShaped(w, options, Order); err != nil {
 		log.Printf("OrderHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
 		return
 	}
@@ -88,6 +113,12 @@ func (h *OrderHandler) GetOrderById(w http.ResponseWriter, r *http.Request, ps h
 func (h *OrderHandler) GetOrdersByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	start := time.Now()
 
+	options, ok := parseShape(w, r, models.Order{})
+	if !ok {
+		log.Printf("OrderHandler.Search: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
 	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
 	err := json.NewDecoder(r.Body).Decode(&query.Filters)
 	if err != nil {
@@ -98,12 +129,17 @@ func (h *OrderHandler) GetOrdersByCriteria(w http.ResponseWriter, r *http.Reques
 	Orders, err := h.OrderService.SearchOrders(query)
 	if err != nil {
 		log.Printf("OrderHandler.Search: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
+	if scope := auth.CustomerScope(r.Context()); scope != 0 {
+		Orders = slices.DeleteFunc(Orders, func(order models.Order) bool {
+			return order.Customer.ID != scope
+		})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(Orders); err != nil {
+	if err := encodeShaped(w, options, Orders); err != nil {
 		log.Printf("OrderHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
 		return
 	}
@@ -117,26 +153,62 @@ func (h *OrderHandler) UpdateOrderById(w http.ResponseWriter, r *http.Request, p
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("OrderHandler.Update: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Order ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
+		return
+	}
+
+	existing, err := h.OrderService.GetOrder(id)
+	if err != nil {
+		log.Printf("OrderHandler.Update: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if outOfScope(r, existing.Customer.ID) {
+		log.Printf("OrderHandler.Update: forbidden order %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("OrderHandler.Update: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	var Order models.Order
-	err = json.NewDecoder(r.Body).Decode(&Order)
+	err = decodeInput(w, r, &Order)
 	if err != nil {
 		log.Printf("OrderHandler.Update: invalid input error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid input: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	Order.ID = id
+	Order.Version = version
+	if auth.CustomerScope(r.Context()) != 0 {
+		Order.Customer = existing.Customer
+	}
+
+	if err := validateInput(w, Order); err != nil {
+		log.Printf("OrderHandler.Update: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if !allowedChanges(w, r, "orders", existing, Order) {
+		log.Printf("OrderHandler.Update: forbidden field change, duration: %v", time.Since(start))
+		return
+	}
 
 	updatedOrder, err := h.OrderService.UpdateOrder(Order)
+	if writeVersionConflict(w, err) {
+		log.Printf("OrderHandler.Update: version conflict, duration: %v", time.Since(start))
+		return
+	}
 	if err != nil {
 		log.Printf("OrderHandler.Update: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Order not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(updatedOrder.Version))
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(updatedOrder); err != nil {
 		log.Printf("OrderHandler.Update: encoding error: %v, duration: %v", err, time.Since(start))
@@ -146,23 +218,251 @@ func (h *OrderHandler) UpdateOrderById(w http.ResponseWriter, r *http.Request, p
 	log.Printf("OrderHandler.Update: success, duration: %v", time.Since(start))
 }
 
+// PatchOrderById applies a JSON merge patch or a JSON patch to an order.
+func (h *OrderHandler) PatchOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("OrderHandler.Patch: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
+		return
+	}
+
+	existing, err := h.OrderService.GetOrder(id)
+	if err != nil {
+		log.Printf("OrderHandler.Patch: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if outOfScope(r, existing.Customer.ID) {
+		log.Printf("OrderHandler.Patch: forbidden order %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("OrderHandler.Patch: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	Order, ok := applyPatch(w, r, "orders", existing)
+	if !ok {
+		log.Printf("OrderHandler.Patch: invalid patch, duration: %v", time.Since(start))
+		return
+	}
+	Order.ID = id
+	Order.Version = version
+	if auth.CustomerScope(r.Context()) != 0 {
+		Order.Customer = existing.Customer
+	}
+
+	updatedOrder, err := h.OrderService.UpdateOrder(Order)
+	if writeVersionConflict(w, err) {
+		log.Printf("OrderHandler.Patch: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("OrderHandler.Patch: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedOrder.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedOrder); err != nil {
+		log.Printf("OrderHandler.Patch: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("OrderHandler.Patch: success, duration: %v", time.Since(start))
+}
+
 func (h *OrderHandler) DeleteOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	start := time.Now()
 
 	id, err := strconv.Atoi(ps.ByName("id"))
 	if err != nil {
 		log.Printf("OrderHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Invalid Order ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
+		return
+	}
+
+	existing, err := h.OrderService.GetOrder(id)
+	if err != nil {
+		log.Printf("OrderHandler.Delete: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if outOfScope(r, existing.Customer.ID) {
+		log.Printf("OrderHandler.Delete: forbidden order %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	if writeDeletePlan(w, r, "orders", id) {
+		log.Printf("OrderHandler.Delete: dry run, duration: %v", time.Since(start))
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("OrderHandler.Delete: precondition failed, duration: %v", time.Since(start))
 		return
 	}
 
 	err = h.OrderService.DeleteOrder(id)
 	if err != nil {
 		log.Printf("OrderHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
-		http.Error(w, "Order not found: "+err.Error(), http.StatusNotFound)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 	log.Printf("OrderHandler.Delete: success, duration: %v", time.Since(start))
 }
+
+// GetCustomerOrders searches the orders of a customer with the filters, sorting and pagination
+// of GetOrdersByCriteria.
+func (h *OrderHandler) GetCustomerOrders(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	options, ok := parseShape(w, r, models.Order{})
+	if !ok {
+		log.Printf("OrderHandler.CustomerOrders: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("OrderHandler.CustomerOrders: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid customer id")
+		return
+	}
+	if outOfScope(r, id) {
+		log.Printf("OrderHandler.CustomerOrders: forbidden customer %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("OrderHandler.CustomerOrders: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	orders, err := h.OrderService.CustomerOrders(id, query)
+	if err != nil {
+		log.Printf("OrderHandler.CustomerOrders: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := encodeShaped(w, options, orders); err != nil {
+		log.Printf("OrderHandler.CustomerOrders: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("OrderHandler.CustomerOrders: success, returned %d orders, duration: %v", len(orders), time.Since(start))
+}
+
+// GetOrderItems lists the items of an order, filtered by book_id or status.
+func (h *OrderHandler) GetOrderItems(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	options, ok := parseShape(w, r, models.OrderItem{})
+	if !ok {
+		log.Printf("OrderHandler.Items: invalid shape, duration: %v", time.Since(start))
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("OrderHandler.Items: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
+		return
+	}
+
+	order, err := h.OrderService.GetOrder(id)
+	if err != nil {
+		log.Printf("OrderHandler.Items: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if outOfScope(r, order.Customer.ID) {
+		log.Printf("OrderHandler.Items: forbidden order %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("OrderHandler.Items: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	items, err := h.OrderService.OrderItems(id, query)
+	if err != nil {
+		log.Printf("OrderHandler.Items: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := encodeShaped(w, options, items); err != nil {
+		log.Printf("OrderHandler.Items: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("OrderHandler.Items: success, returned %d items, duration: %v", len(items), time.Since(start))
+}
+
+// AddOrderItem adds an item to an order and allocates it.
+func (h *OrderHandler) AddOrderItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("OrderHandler.AddItem: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
+		return
+	}
+
+	order, err := h.OrderService.GetOrder(id)
+	if err != nil {
+		log.Printf("OrderHandler.AddItem: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if outOfScope(r, order.Customer.ID) {
+		log.Printf("OrderHandler.AddItem: forbidden order %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+
+	var item models.OrderItem
+	if err := decodeInput(w, r, &item); err != nil {
+		log.Printf("OrderHandler.AddItem: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+	if err := validateInput(w, item); err != nil {
+		log.Printf("OrderHandler.AddItem: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	createdItem, err := h.OrderService.AddOrderItem(id, item)
+	if err != nil {
+		log.Printf("OrderHandler.AddItem: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdItem); err != nil {
+		log.Printf("OrderHandler.AddItem: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("OrderHandler.AddItem: success, duration: %v", time.Since(start))
+}
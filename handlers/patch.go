@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+
+	"bookstore.com/auth"
+	"bookstore.com/patch"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// FieldPermissions restricts the fields the callers may change through PUT and PATCH
+var FieldPermissions auth.FieldPermissions
+
+// readOnlyFields cannot be patched, the version is given with If-Match
+var readOnlyFields = []string{"id", "version"}
+
+// applyPatch applies the RFC 7396 merge patch or the RFC 6902 JSON patch of a PATCH request,
+// told apart by the Content-Type, to the current state of an entity and decodes the result
+// back into the model. The changed fields are checked against the field permissions of the
+// resource and the result against the validation rules of the model. It answers the error
+// itself and returns false when the patch cannot be applied.
+func applyPatch[T any](w http.ResponseWriter, r *http.Request, resource string, current T) (T, bool) {
+	var patched T
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergePatchType && mediaType != jsonPatchType {
+		w.Header().Set("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		writeError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "PATCH needs a "+mergePatchType+" or "+jsonPatchType+" body")
+		return patched, false
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return patched, false
+	}
+
+	original, err := toDocument(current)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return patched, false
+	}
+	document, _ := toDocument(current)
+	if mediaType == mergePatchType {
+		var mergePatch interface{}
+		if err := json.Unmarshal(body, &mergePatch); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "invalid merge patch: "+err.Error())
+			return patched, false
+		}
+		document = patch.MergePatch(document, mergePatch)
+	} else {
+		var operations []patch.Operation
+		if err := json.Unmarshal(body, &operations); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON patch: "+err.Error())
+			return patched, false
+		}
+		document, err = patch.Apply(document, operations)
+		if errors.Is(err, patch.ErrTestFailed) {
+			writeError(w, http.StatusConflict, "conflict", err.Error())
+			return patched, false
+		}
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "unprocessable_entity", err.Error())
+			return patched, false
+		}
+	}
+
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "unprocessable_entity", "the patched document must be an object")
+		return patched, false
+	}
+	fields := changedFields(original.(map[string]interface{}), object)
+	for _, field := range fields {
+		if slices.Contains(readOnlyFields, field) {
+			writeError(w, http.StatusUnprocessableEntity, "unprocessable_entity", "field "+field+" is read-only")
+			return patched, false
+		}
+	}
+	if !allowedFields(w, r, resource, fields) {
+		return patched, false
+	}
+
+	// the result must still be a valid model, unknown fields included
+	data, err := json.Marshal(object)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return patched, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		writeDecodeError(w, err)
+		return patched, false
+	}
+	if err := validateInput(w, patched); err != nil {
+		return patched, false
+	}
+	return patched, true
+}
+
+// allowedChanges checks the fields a PUT changes by replacing the current state of an entity
+// against the field permissions of the resource, the id and the version being set by the
+// route. It answers 403 itself and returns false when a change is not allowed.
+func allowedChanges(w http.ResponseWriter, r *http.Request, resource string, current, replacement interface{}) bool {
+	before, err := toDocument(current)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return false
+	}
+	after, err := toDocument(replacement)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return false
+	}
+	var fields []string
+	for _, field := range changedFields(before.(map[string]interface{}), after.(map[string]interface{})) {
+		if !slices.Contains(readOnlyFields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return allowedFields(w, r, resource, fields)
+}
+
+// allowedFields answers 403 when the caller may not change one of the fields of the resource
+// and returns whether all the changes are allowed
+func allowedFields(w http.ResponseWriter, r *http.Request, resource string, fields []string) bool {
+	principal, _ := auth.FromContext(r.Context())
+	for _, field := range fields {
+		if !FieldPermissions.Allows(principal, resource, field) {
+			writeAuthError(w, http.StatusForbidden, "not allowed to change the field "+field+" of "+resource)
+			return false
+		}
+	}
+	return true
+}
+
+// toDocument converts a model to its decoded JSON document
+func toDocument(model interface{}) (interface{}, error) {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	err = json.Unmarshal(data, &document)
+	return document, err
+}
+
+// changedFields returns the top-level members that differ between two documents, sorted
+func changedFields(before, after map[string]interface{}) []string {
+	var fields []string
+	for name, value := range before {
+		if !reflect.DeepEqual(value, after[name]) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range after {
+		if _, exists := before[name]; !exists {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
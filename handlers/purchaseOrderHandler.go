@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// PurchaseOrderHandler handles purchase order related HTTP requests.
+type PurchaseOrderHandler struct {
+	PurchaseOrderService *services.PurchaseOrderService
+}
+
+var (
+	PurchaseOrderInstance *PurchaseOrderHandler
+	PurchaseOrderOnce     sync.Once
+)
+
+// NewPurchaseOrderHandler initializes a singleton instance of PurchaseOrderHandler.
+func NewPurchaseOrderHandler(PurchaseOrderService *services.PurchaseOrderService) *PurchaseOrderHandler {
+	PurchaseOrderOnce.Do(func() {
+		PurchaseOrderInstance = &PurchaseOrderHandler{PurchaseOrderService: PurchaseOrderService}
+	})
+	return PurchaseOrderInstance
+}
+
+// receiveRequest is the body of POST /purchaseOrders/:id/receive
+type receiveRequest struct {
+	User  string                        `json:"user"`
+	Lines []models.PurchaseOrderReceipt `json:"lines"`
+}
+
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var order models.PurchaseOrder
+	if err := decodeInput(w, r, &order); err != nil {
+		log.Printf("PurchaseOrderHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if err := validateInput(w, order); err != nil {
+		log.Printf("PurchaseOrderHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	createdOrder, err := h.PurchaseOrderService.CreatePurchaseOrder(order)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Create: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdOrder); err != nil {
+		log.Printf("PurchaseOrderHandler.Create: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.Create: success, duration: %v", time.Since(start))
+}
+
+func (h *PurchaseOrderHandler) GetPurchaseOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid purchase order id")
+		return
+	}
+
+	order, err := h.PurchaseOrderService.GetPurchaseOrder(id)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, order.Version) {
+		log.Printf("PurchaseOrderHandler.GetById: not modified, duration: %v", time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		log.Printf("PurchaseOrderHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.GetById: success, duration: %v", time.Since(start))
+}
+
+func (h *PurchaseOrderHandler) GetPurchaseOrdersByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("PurchaseOrderHandler.Search: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	orders, err := h.PurchaseOrderService.SearchPurchaseOrders(query)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Search: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(orders); err != nil {
+		log.Printf("PurchaseOrderHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.Search: success, returned %d purchase orders, duration: %v", len(orders), time.Since(start))
+}
+
+func (h *PurchaseOrderHandler) UpdatePurchaseOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Update: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid purchase order id")
+		return
+	}
+
+	existing, err := h.PurchaseOrderService.GetPurchaseOrder(id)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Update: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("PurchaseOrderHandler.Update: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	var order models.PurchaseOrder
+	if err = decodeInput(w, r, &order); err != nil {
+		log.Printf("PurchaseOrderHandler.Update: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+	order.ID = id
+	order.Version = version
+
+	if err := validateInput(w, order); err != nil {
+		log.Printf("PurchaseOrderHandler.Update: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if !allowedChanges(w, r, "purchaseOrders", existing, order) {
+		log.Printf("PurchaseOrderHandler.Update: forbidden field change, duration: %v", time.Since(start))
+		return
+	}
+
+	updatedOrder, err := h.PurchaseOrderService.UpdatePurchaseOrder(order)
+	if writeVersionConflict(w, err) {
+		log.Printf("PurchaseOrderHandler.Update: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Update: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedOrder.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedOrder); err != nil {
+		log.Printf("PurchaseOrderHandler.Update: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.Update: success, duration: %v", time.Since(start))
+}
+
+// PatchPurchaseOrderById applies a JSON merge patch or a JSON patch to a draft purchase order.
+func (h *PurchaseOrderHandler) PatchPurchaseOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Patch: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid purchase order id")
+		return
+	}
+
+	existing, err := h.PurchaseOrderService.GetPurchaseOrder(id)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Patch: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("PurchaseOrderHandler.Patch: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	order, ok := applyPatch(w, r, "purchaseOrders", existing)
+	if !ok {
+		log.Printf("PurchaseOrderHandler.Patch: invalid patch, duration: %v", time.Since(start))
+		return
+	}
+	order.ID = id
+	order.Version = version
+
+	updatedOrder, err := h.PurchaseOrderService.UpdatePurchaseOrder(order)
+	if writeVersionConflict(w, err) {
+		log.Printf("PurchaseOrderHandler.Patch: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Patch: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedOrder.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedOrder); err != nil {
+		log.Printf("PurchaseOrderHandler.Patch: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.Patch: success, duration: %v", time.Since(start))
+}
+
+func (h *PurchaseOrderHandler) DeletePurchaseOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid purchase order id")
+		return
+	}
+
+	existing, err := h.PurchaseOrderService.GetPurchaseOrder(id)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Delete: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("PurchaseOrderHandler.Delete: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	if err = h.PurchaseOrderService.DeletePurchaseOrder(id); err != nil {
+		log.Printf("PurchaseOrderHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("PurchaseOrderHandler.Delete: success, duration: %v", time.Since(start))
+}
+
+// SendPurchaseOrderById moves a draft purchase order to sent.
+func (h *PurchaseOrderHandler) SendPurchaseOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Send: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid purchase order id")
+		return
+	}
+
+	order, err := h.PurchaseOrderService.SendPurchaseOrder(id)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Send: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		log.Printf("PurchaseOrderHandler.Send: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.Send: success, duration: %v", time.Since(start))
+}
+
+// ReceivePurchaseOrderById records a delivery against the lines of a sent purchase order.
+func (h *PurchaseOrderHandler) ReceivePurchaseOrderById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Receive: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid purchase order id")
+		return
+	}
+
+	var request receiveRequest
+	if err = decodeInput(w, r, &request); err != nil {
+		log.Printf("PurchaseOrderHandler.Receive: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	order, err := h.PurchaseOrderService.ReceivePurchaseOrder(id, request.Lines, request.User)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Receive: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		log.Printf("PurchaseOrderHandler.Receive: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.Receive: success, duration: %v", time.Since(start))
+}
+
+// GetSupplierCosts returns the costs of the books received from a supplier.
+func (h *PurchaseOrderHandler) GetSupplierCosts(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.SupplierCosts: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid supplier id")
+		return
+	}
+
+	costs, err := h.PurchaseOrderService.SupplierCosts(id)
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.SupplierCosts: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(costs); err != nil {
+		log.Printf("PurchaseOrderHandler.SupplierCosts: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.SupplierCosts: success, duration: %v", time.Since(start))
+}
+
+// GetMargins returns the margin report of the books received from the suppliers.
+func (h *PurchaseOrderHandler) GetMargins(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	margins, err := h.PurchaseOrderService.Margins()
+	if err != nil {
+		log.Printf("PurchaseOrderHandler.Margins: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(margins); err != nil {
+		log.Printf("PurchaseOrderHandler.Margins: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("PurchaseOrderHandler.Margins: success, duration: %v", time.Since(start))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookstore.com/auth"
+	"bookstore.com/ratelimit"
+)
+
+// RateLimitMiddleware limits the requests of each client per route group with token buckets.
+// Clients are identified by their API key, their authenticated subject or their IP address,
+// so it runs after the AuthMiddleware.
+type RateLimitMiddleware struct {
+	Limiter ratelimit.Limiter // nil when rate limiting is off
+	Policy  ratelimit.Policy
+}
+
+func (m *RateLimitMiddleware) Handler(next http.Handler) http.Handler {
+	if m.Limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group, rate := m.Policy.Lookup(r.Method, r.URL.Path)
+		client := clientKey(r)
+		result, err := m.Limiter.Take(group+"|"+client, rate, time.Now())
+		if err != nil {
+			// a limiter failure must not take the API down
+			log.Printf("RateLimitMiddleware: %s %s: limiter error: %v", r.Method, r.URL.Path, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", strconv.Itoa(rate.Burst)+";w="+strconv.Itoa(seconds(rate.Period)))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		if !result.Allowed {
+			log.Printf("RateLimitMiddleware: %s %s: %s over the %q limit", r.Method, r.URL.Path, client, group)
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds(result.RetryAfter), 1)))
+			writeError(w, http.StatusTooManyRequests, "too_many_requests", "rate limit of "+strconv.Itoa(rate.Burst)+" requests per "+rate.Period.String()+" exceeded for "+group)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the client of a request
+func clientKey(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		if principal.APIKeyID != 0 {
+			return "api_key:" + strconv.Itoa(principal.APIKeyID)
+		}
+		return "user:" + principal.Subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds rounds a duration up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
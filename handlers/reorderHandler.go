@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// ReorderHandler exposes the reorder suggestions of the low stock monitor.
+type ReorderHandler struct {
+	ReorderService *services.ReorderService
+}
+
+var (
+	ReorderInstance *ReorderHandler
+	ReorderOnce     sync.Once
+)
+
+// NewReorderHandler initializes a singleton instance of ReorderHandler.
+func NewReorderHandler(ReorderService *services.ReorderService) *ReorderHandler {
+	ReorderOnce.Do(func() {
+		ReorderInstance = &ReorderHandler{ReorderService: ReorderService}
+	})
+	return ReorderInstance
+}
+
+// GetReorderSuggestions lists the books at or below their reorder threshold.
+func (h *ReorderHandler) GetReorderSuggestions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	suggestions, err := h.ReorderService.Suggestions(time.Now())
+	if err != nil {
+		log.Printf("ReorderHandler.Suggestions: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+		log.Printf("ReorderHandler.Suggestions: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("ReorderHandler.Suggestions: success, returned %d suggestions, duration: %v", len(suggestions), time.Since(start))
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookstore.com/export"
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// SalesReportHandler handles the sales report history requests.
+type SalesReportHandler struct {
+	SalesReportService *services.SalesReportService
+}
+
+var (
+	SalesReportInstance *SalesReportHandler
+	SalesReportOnce     sync.Once
+)
+
+// NewSalesReportHandler initializes a singleton instance of SalesReportHandler.
+func NewSalesReportHandler(SalesReportService *services.SalesReportService) *SalesReportHandler {
+	SalesReportOnce.Do(func() {
+		SalesReportInstance = &SalesReportHandler{SalesReportService: SalesReportService}
+	})
+	return SalesReportInstance
+}
+
+// reportRequest is the body of POST /reports, Date defaults to the previous period
+type reportRequest struct {
+	Period string    `json:"period"`
+	Date   time.Time `json:"date"`
+}
+
+// CreateReport generates and stores the report of the period containing the requested date.
+func (h *SalesReportHandler) CreateReport(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var request reportRequest
+	if err := decodeInput(w, r, &request); err != nil {
+		log.Printf("SalesReportHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+	if request.Date.IsZero() {
+		from, _, err := services.PeriodBounds(request.Period, time.Now())
+		if err != nil {
+			log.Printf("SalesReportHandler.Create: invalid period error: %v, duration: %v", err, time.Since(start))
+			writeServiceError(w, err)
+			return
+		}
+		request.Date = from.Add(-time.Nanosecond)
+	}
+
+	report, err := h.SalesReportService.CreateReport(request.Period, request.Date)
+	if err != nil {
+		log.Printf("SalesReportHandler.Create: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("SalesReportHandler.Create: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SalesReportHandler.Create: success, duration: %v", time.Since(start))
+}
+
+// GetReportById returns a report as JSON or exported as CSV, XLSX or PDF.
+func (h *SalesReportHandler) GetReportById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		log.Printf("SalesReportHandler.GetById: format error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusNotAcceptable, "not_acceptable", err.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("SalesReportHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid report id")
+		return
+	}
+
+	report, err := h.SalesReportService.GetReport(id)
+	if err != nil {
+		log.Printf("SalesReportHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if format != export.JSON {
+		filename := "sales-report-" + report.Period + "-" + report.From.Format("2006-01-02")
+		if err := writeExport(w, format, filename, "Sales report "+report.Period+" "+report.From.Format("2006-01-02"), export.SalesReportTables(report)); err != nil {
+			log.Printf("SalesReportHandler.GetById: export error: %v, duration: %v", err, time.Since(start))
+			return
+		}
+		log.Printf("SalesReportHandler.GetById: exported %s, duration: %v", format, time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("SalesReportHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SalesReportHandler.GetById: success, duration: %v", time.Since(start))
+}
+
+// GetReportsByCriteria lists the report history, filtered by period, from and to,
+// as JSON or exported as CSV, XLSX or PDF.
+func (h *SalesReportHandler) GetReportsByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		log.Printf("SalesReportHandler.Search: format error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusNotAcceptable, "not_acceptable", err.Error())
+		return
+	}
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("SalesReportHandler.Search: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	reports, err := h.SalesReportService.SearchReports(query)
+	if err != nil {
+		log.Printf("SalesReportHandler.Search: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if format != export.JSON {
+		if err := writeExport(w, format, "sales-reports", "Sales reports", []export.Table{export.SalesReportHistoryTable(reports)}); err != nil {
+			log.Printf("SalesReportHandler.Search: export error: %v, duration: %v", err, time.Since(start))
+			return
+		}
+		log.Printf("SalesReportHandler.Search: exported %d reports as %s, duration: %v", len(reports), format, time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		log.Printf("SalesReportHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SalesReportHandler.Search: success, returned %d reports, duration: %v", len(reports), time.Since(start))
+}
+
+// CompareReportById compares a report with the report of the previous period.
+func (h *SalesReportHandler) CompareReportById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("SalesReportHandler.Compare: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid report id")
+		return
+	}
+
+	comparison, err := h.SalesReportService.CompareReport(id)
+	if err != nil {
+		log.Printf("SalesReportHandler.Compare: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(comparison); err != nil {
+		log.Printf("SalesReportHandler.Compare: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SalesReportHandler.Compare: success, duration: %v", time.Since(start))
+}
+
+func (h *SalesReportHandler) DeleteReportById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("SalesReportHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid report id")
+		return
+	}
+
+	if err = h.SalesReportService.DeleteReport(id); err != nil {
+		log.Printf("SalesReportHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("SalesReportHandler.Delete: success, duration: %v", time.Since(start))
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"bookstore.com/auth"
+)
+
+// outOfScope reports whether a logged-in customer tries to reach the records of another customer
+func outOfScope(r *http.Request, customerID int) bool {
+	scope := auth.CustomerScope(r.Context())
+	return scope != 0 && scope != customerID
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// SearchHandler handles the full-text search of the catalog.
+type SearchHandler struct {
+	CatalogSearchService *services.CatalogSearchService
+}
+
+var (
+	SearchInstance *SearchHandler
+	SearchOnce     sync.Once
+)
+
+// NewSearchHandler initializes a singleton instance of SearchHandler.
+func NewSearchHandler(CatalogSearchService *services.CatalogSearchService) *SearchHandler {
+	SearchOnce.Do(func() {
+		SearchInstance = &SearchHandler{CatalogSearchService: CatalogSearchService}
+	})
+	return SearchInstance
+}
+
+// Search ranks the books matching ?q= by relevance, paginated with ?limit= and ?offset=.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		log.Printf("SearchHandler.Search: missing query, duration: %v", time.Since(start))
+		writeError(w, http.StatusBadRequest, "missing_query", "the q parameter is required")
+		return
+	}
+	limit, offset, ok := pageParameters(w, r, 20, 100)
+	if !ok {
+		log.Printf("SearchHandler.Search: invalid page, duration: %v", time.Since(start))
+		return
+	}
+
+	results, err := h.CatalogSearchService.Search(query, limit, offset)
+	if err != nil {
+		log.Printf("SearchHandler.Search: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		log.Printf("SearchHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SearchHandler.Search: success, returned %d of %d books, duration: %v", len(results.Hits), results.Total, time.Since(start))
+}
+
+// pageParameters reads ?limit= (defaultLimit when missing, at most maxLimit) and ?offset=,
+// it answers 400 when they are invalid and returns whether they are valid
+func pageParameters(w http.ResponseWriter, r *http.Request, defaultLimit, maxLimit int) (int, int, bool) {
+	limit, offset := defaultLimit, 0
+	var err error
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxLimit {
+			writeError(w, http.StatusBadRequest, "invalid_page", "limit must be a number between 1 and "+strconv.Itoa(maxLimit))
+			return 0, 0, false
+		}
+	}
+	if value := r.URL.Query().Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_page", "offset must be a positive number")
+			return 0, 0, false
+		}
+	}
+	return limit, offset, true
+}
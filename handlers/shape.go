@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"bookstore.com/repositories"
+	"bookstore.com/shape"
+)
+
+// ExpandMaxDepth is how many references an ?expand path may cross, e.g. 2 for items.book.author
+var ExpandMaxDepth = 2
+
+// parseShape reads the ?expand, ?fields, ?sort, ?limit and ?offset parameters of a GET
+// answering the model, it answers 400 when they are invalid and returns whether they are valid
+func parseShape(w http.ResponseWriter, r *http.Request, model interface{}) (shape.Options, bool) {
+	options, err := shape.Parse(r.URL.Query(), model, ExpandMaxDepth)
+	if err != nil {
+		code := "bad_request"
+		var domainError *repositories.Error
+		if errors.As(err, &domainError) {
+			code = domainError.Code
+		}
+		writeError(w, http.StatusBadRequest, code, err.Error())
+		return shape.Options{}, false
+	}
+	return options, true
+}
+
+// encodeShaped encodes an entity or a slice of entities in the shape of the options, a
+// paginated list tells its total number of entities in X-Total-Count
+func encodeShaped(w http.ResponseWriter, options shape.Options, v interface{}) error {
+	if list := reflect.ValueOf(v); list.Kind() == reflect.Slice && options.Paginated() {
+		w.Header().Set("X-Total-Count", strconv.Itoa(list.Len()))
+	}
+	shaped, err := options.Apply(v)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(shaped)
+}
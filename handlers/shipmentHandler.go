@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookstore.com/auth"
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// ShipmentHandler handles the shipments of the orders and their tracking.
+type ShipmentHandler struct {
+	ShipmentService *services.ShipmentService
+}
+
+var (
+	ShipmentInstance *ShipmentHandler
+	ShipmentOnce     sync.Once
+)
+
+// NewShipmentHandler initializes a singleton instance of ShipmentHandler.
+func NewShipmentHandler(ShipmentService *services.ShipmentService) *ShipmentHandler {
+	ShipmentOnce.Do(func() {
+		ShipmentInstance = &ShipmentHandler{ShipmentService: ShipmentService}
+	})
+	return ShipmentInstance
+}
+
+// CreateShipment ships allocated items of an order and buys the carrier label.
+func (h *ShipmentHandler) CreateShipment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("ShipmentHandler.Create: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
+		return
+	}
+
+	var shipment models.Shipment
+	if err := decodeInput(w, r, &shipment); err != nil {
+		log.Printf("ShipmentHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	createdShipment, err := h.ShipmentService.CreateShipment(id, shipment)
+	if err != nil {
+		log.Printf("ShipmentHandler.Create: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdShipment); err != nil {
+		log.Printf("ShipmentHandler.Create: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("ShipmentHandler.Create: success, duration: %v", time.Since(start))
+}
+
+// GetOrderShipments returns the shipments of an order.
+func (h *ShipmentHandler) GetOrderShipments(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("ShipmentHandler.OrderShipments: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid order id")
+		return
+	}
+
+	shipments, err := h.ShipmentService.OrderShipments(id, auth.CustomerScope(r.Context()))
+	if errors.Is(err, services.ErrForbidden) {
+		log.Printf("ShipmentHandler.OrderShipments: forbidden order %d, duration: %v", id, time.Since(start))
+		writeAuthError(w, http.StatusForbidden, "not allowed to access the records of another customer")
+		return
+	}
+	if err != nil {
+		log.Printf("ShipmentHandler.OrderShipments: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(shipments); err != nil {
+		log.Printf("ShipmentHandler.OrderShipments: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("ShipmentHandler.OrderShipments: success, returned %d shipments, duration: %v", len(shipments), time.Since(start))
+}
+
+func (h *ShipmentHandler) GetShipmentById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("ShipmentHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid shipment id")
+		return
+	}
+
+	shipment, err := h.ShipmentService.GetShipment(id)
+	if err != nil {
+		log.Printf("ShipmentHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, shipment.Version) {
+		log.Printf("ShipmentHandler.GetById: not modified, duration: %v", time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(shipment); err != nil {
+		log.Printf("ShipmentHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("ShipmentHandler.GetById: success, duration: %v", time.Since(start))
+}
+
+func (h *ShipmentHandler) GetShipmentsByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	if err := json.NewDecoder(r.Body).Decode(&query.Filters); err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("ShipmentHandler.Search: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	shipments, err := h.ShipmentService.SearchShipments(query)
+	if err != nil {
+		log.Printf("ShipmentHandler.Search: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(shipments); err != nil {
+		log.Printf("ShipmentHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("ShipmentHandler.Search: success, returned %d shipments, duration: %v", len(shipments), time.Since(start))
+}
+
+// TrackShipmentById polls the carrier for the tracking events of a shipment.
+func (h *ShipmentHandler) TrackShipmentById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("ShipmentHandler.Track: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid shipment id")
+		return
+	}
+
+	shipment, err := h.ShipmentService.Track(id)
+	if err != nil {
+		log.Printf("ShipmentHandler.Track: service error: %v, duration: %v", err, time.Since(start))
+		var domainError *repositories.Error
+		if errors.As(err, &domainError) {
+			writeServiceError(w, err)
+			return
+		}
+		// the other errors come from the carrier
+		writeError(w, http.StatusBadGateway, "carrier_error", "cannot track shipment: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(shipment); err != nil {
+		log.Printf("ShipmentHandler.Track: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("ShipmentHandler.Track: success, duration: %v", time.Since(start))
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// SuggestHandler handles the autocompletion of the titles and author names.
+type SuggestHandler struct {
+	SuggestService *services.SuggestService
+}
+
+var (
+	SuggestInstance *SuggestHandler
+	SuggestOnce     sync.Once
+)
+
+// NewSuggestHandler initializes a singleton instance of SuggestHandler.
+func NewSuggestHandler(SuggestService *services.SuggestService) *SuggestHandler {
+	SuggestOnce.Do(func() {
+		SuggestInstance = &SuggestHandler{SuggestService: SuggestService}
+	})
+	return SuggestInstance
+}
+
+// Suggest completes the titles and author names starting like ?q=, at most ?limit= of them.
+func (h *SuggestHandler) Suggest(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		log.Printf("SuggestHandler.Suggest: missing query, duration: %v", time.Since(start))
+		writeError(w, http.StatusBadRequest, "missing_query", "the q parameter is required")
+		return
+	}
+	limit, _, ok := pageParameters(w, r, 10, 50)
+	if !ok {
+		log.Printf("SuggestHandler.Suggest: invalid page, duration: %v", time.Since(start))
+		return
+	}
+
+	suggestions := h.SuggestService.Suggest(query, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+		log.Printf("SuggestHandler.Suggest: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SuggestHandler.Suggest: success, returned %d suggestions, duration: %v", len(suggestions), time.Since(start))
+}
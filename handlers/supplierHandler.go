@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// SupplierHandler handles supplier-related HTTP requests.
+type SupplierHandler struct {
+	SupplierService *services.SupplierService
+}
+
+var (
+	SupplierInstance *SupplierHandler
+	SupplierOnce     sync.Once
+)
+
+// NewSupplierHandler initializes a singleton instance of SupplierHandler.
+func NewSupplierHandler(SupplierService *services.SupplierService) *SupplierHandler {
+	SupplierOnce.Do(func() {
+		SupplierInstance = &SupplierHandler{SupplierService: SupplierService}
+	})
+	return SupplierInstance
+}
+
+func (h *SupplierHandler) CreateSupplier(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var Supplier models.Supplier
+	err := decodeInput(w, r, &Supplier)
+	if err != nil {
+		log.Printf("SupplierHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if err := validateInput(w, Supplier); err != nil {
+		log.Printf("SupplierHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	createdSupplier, err := h.SupplierService.CreateSupplier(Supplier)
+	if err != nil {
+		log.Printf("SupplierHandler.Create: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdSupplier); err != nil {
+		log.Printf("SupplierHandler.Create: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SupplierHandler.Create: success, duration: %v", time.Since(start))
+}
+
+func (h *SupplierHandler) GetSupplierById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("SupplierHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid supplier id")
+		return
+	}
+
+	Supplier, err := h.SupplierService.GetSupplier(id)
+	if err != nil {
+		log.Printf("SupplierHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, Supplier.Version) {
+		log.Printf("SupplierHandler.GetById: not modified, duration: %v", time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(Supplier); err != nil {
+		log.Printf("SupplierHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SupplierHandler.GetById: success, duration: %v", time.Since(start))
+}
+
+func (h *SupplierHandler) GetSuppliersByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	err := json.NewDecoder(r.Body).Decode(&query.Filters)
+	if err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("SupplierHandler.Search: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	Suppliers, err := h.SupplierService.SearchSuppliers(query)
+	if err != nil {
+		log.Printf("SupplierHandler.Search: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(Suppliers); err != nil {
+		log.Printf("SupplierHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SupplierHandler.Search: success, returned %d suppliers, duration: %v", len(Suppliers), time.Since(start))
+}
+
+func (h *SupplierHandler) UpdateSupplierById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("SupplierHandler.Update: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid supplier id")
+		return
+	}
+
+	existing, err := h.SupplierService.GetSupplier(id)
+	if err != nil {
+		log.Printf("SupplierHandler.Update: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("SupplierHandler.Update: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	var Supplier models.Supplier
+	err = decodeInput(w, r, &Supplier)
+	if err != nil {
+		log.Printf("SupplierHandler.Update: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+	Supplier.ID = id
+	Supplier.Version = version
+
+	if err := validateInput(w, Supplier); err != nil {
+		log.Printf("SupplierHandler.Update: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if !allowedChanges(w, r, "suppliers", existing, Supplier) {
+		log.Printf("SupplierHandler.Update: forbidden field change, duration: %v", time.Since(start))
+		return
+	}
+
+	updatedSupplier, err := h.SupplierService.UpdateSupplier(Supplier)
+	if writeVersionConflict(w, err) {
+		log.Printf("SupplierHandler.Update: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("SupplierHandler.Update: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedSupplier.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedSupplier); err != nil {
+		log.Printf("SupplierHandler.Update: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SupplierHandler.Update: success, duration: %v", time.Since(start))
+}
+
+// PatchSupplierById applies a JSON merge patch or a JSON patch to a supplier.
+func (h *SupplierHandler) PatchSupplierById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("SupplierHandler.Patch: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid supplier id")
+		return
+	}
+
+	existing, err := h.SupplierService.GetSupplier(id)
+	if err != nil {
+		log.Printf("SupplierHandler.Patch: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("SupplierHandler.Patch: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	Supplier, ok := applyPatch(w, r, "suppliers", existing)
+	if !ok {
+		log.Printf("SupplierHandler.Patch: invalid patch, duration: %v", time.Since(start))
+		return
+	}
+	Supplier.ID = id
+	Supplier.Version = version
+
+	updatedSupplier, err := h.SupplierService.UpdateSupplier(Supplier)
+	if writeVersionConflict(w, err) {
+		log.Printf("SupplierHandler.Patch: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("SupplierHandler.Patch: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedSupplier.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedSupplier); err != nil {
+		log.Printf("SupplierHandler.Patch: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("SupplierHandler.Patch: success, duration: %v", time.Since(start))
+}
+
+func (h *SupplierHandler) DeleteSupplierById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("SupplierHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid supplier id")
+		return
+	}
+
+	existing, err := h.SupplierService.GetSupplier(id)
+	if err != nil {
+		log.Printf("SupplierHandler.Delete: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if writeDeletePlan(w, r, "suppliers", id) {
+		log.Printf("SupplierHandler.Delete: dry run, duration: %v", time.Since(start))
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("SupplierHandler.Delete: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	err = h.SupplierService.DeleteSupplier(id)
+	if err != nil {
+		log.Printf("SupplierHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("SupplierHandler.Delete: success, duration: %v", time.Since(start))
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"bookstore.com/validation"
+)
+
+// decodeInput decodes a JSON body into a model, rejecting the fields the model does not
+// have. It answers 400 for malformed JSON and 422 for unknown fields or values of the wrong
+// type, then returns the error.
+func decodeInput(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		writeDecodeError(w, err)
+	}
+	return err
+}
+
+// validateInput checks a decoded model against its validation rules and answers 422 with
+// the violations when it breaks any
+func validateInput(w http.ResponseWriter, v interface{}) error {
+	err := validation.Struct(v)
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		writeViolations(w, violations)
+	}
+	return err
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeError):
+		writeViolations(w, validation.Errors{{Field: typeError.Field, Message: "must be " + jsonType(typeError.Type)}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		writeViolations(w, validation.Errors{{Field: field, Message: "is not a known field"}})
+	case errors.Is(err, io.EOF):
+		writeError(w, http.StatusBadRequest, "bad_request", "the request body is empty")
+	default:
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON: "+strings.TrimPrefix(err.Error(), "json: "))
+	}
+}
+
+func writeViolations(w http.ResponseWriter, violations validation.Errors) {
+	writeProblem(w, problem{
+		Status:     http.StatusUnprocessableEntity,
+		Code:       "validation_failed",
+		Detail:     "the input breaks validation rules",
+		Violations: violations,
+	})
+}
+
+// jsonType names the JSON type decoded into a Go type
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/services"
+	"github.com/julienschmidt/httprouter"
+)
+
+// WarehouseHandler handles warehouse-related HTTP requests.
+type WarehouseHandler struct {
+	WarehouseService *services.WarehouseService
+}
+
+var (
+	WarehouseInstance *WarehouseHandler
+	WarehouseOnce     sync.Once
+)
+
+// NewWarehouseHandler initializes a singleton instance of WarehouseHandler.
+func NewWarehouseHandler(WarehouseService *services.WarehouseService) *WarehouseHandler {
+	WarehouseOnce.Do(func() {
+		WarehouseInstance = &WarehouseHandler{WarehouseService: WarehouseService}
+	})
+	return WarehouseInstance
+}
+
+func (h *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var Warehouse models.Warehouse
+	err := decodeInput(w, r, &Warehouse)
+	if err != nil {
+		log.Printf("WarehouseHandler.Create: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if err := validateInput(w, Warehouse); err != nil {
+		log.Printf("WarehouseHandler.Create: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	createdWarehouse, err := h.WarehouseService.CreateWarehouse(Warehouse)
+	if err != nil {
+		log.Printf("WarehouseHandler.Create: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdWarehouse); err != nil {
+		log.Printf("WarehouseHandler.Create: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("WarehouseHandler.Create: success, duration: %v", time.Since(start))
+}
+
+func (h *WarehouseHandler) GetWarehouseById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("WarehouseHandler.GetById: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid warehouse id")
+		return
+	}
+
+	Warehouse, err := h.WarehouseService.GetWarehouse(id)
+	if err != nil {
+		log.Printf("WarehouseHandler.GetById: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	if notModified(w, r, Warehouse.Version) {
+		log.Printf("WarehouseHandler.GetById: not modified, duration: %v", time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(Warehouse); err != nil {
+		log.Printf("WarehouseHandler.GetById: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("WarehouseHandler.GetById: success, duration: %v", time.Since(start))
+}
+
+func (h *WarehouseHandler) GetWarehousesByCriteria(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	start := time.Now()
+
+	var query = models.SearchCriteria{Filters: make(map[string]interface{})}
+	err := json.NewDecoder(r.Body).Decode(&query.Filters)
+	if err != nil {
+		query.Filters = make(map[string]interface{})
+		log.Printf("WarehouseHandler.Search: invalid criteria error: %v, duration: %v", err, time.Since(start))
+	}
+
+	Warehouses, err := h.WarehouseService.SearchWarehouses(query)
+	if err != nil {
+		log.Printf("WarehouseHandler.Search: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(Warehouses); err != nil {
+		log.Printf("WarehouseHandler.Search: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("WarehouseHandler.Search: success, returned %d warehouses, duration: %v", len(Warehouses), time.Since(start))
+}
+
+func (h *WarehouseHandler) UpdateWarehouseById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("WarehouseHandler.Update: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid warehouse id")
+		return
+	}
+
+	existing, err := h.WarehouseService.GetWarehouse(id)
+	if err != nil {
+		log.Printf("WarehouseHandler.Update: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("WarehouseHandler.Update: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	var Warehouse models.Warehouse
+	err = decodeInput(w, r, &Warehouse)
+	if err != nil {
+		log.Printf("WarehouseHandler.Update: invalid input error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+	Warehouse.ID = id
+	Warehouse.Version = version
+
+	if err := validateInput(w, Warehouse); err != nil {
+		log.Printf("WarehouseHandler.Update: validation error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	if !allowedChanges(w, r, "warehouses", existing, Warehouse) {
+		log.Printf("WarehouseHandler.Update: forbidden field change, duration: %v", time.Since(start))
+		return
+	}
+
+	updatedWarehouse, err := h.WarehouseService.UpdateWarehouse(Warehouse)
+	if writeVersionConflict(w, err) {
+		log.Printf("WarehouseHandler.Update: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("WarehouseHandler.Update: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedWarehouse.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedWarehouse); err != nil {
+		log.Printf("WarehouseHandler.Update: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("WarehouseHandler.Update: success, duration: %v", time.Since(start))
+}
+
+// PatchWarehouseById applies a JSON merge patch or a JSON patch to a warehouse.
+func (h *WarehouseHandler) PatchWarehouseById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("WarehouseHandler.Patch: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid warehouse id")
+		return
+	}
+
+	existing, err := h.WarehouseService.GetWarehouse(id)
+	if err != nil {
+		log.Printf("WarehouseHandler.Patch: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	version, ok := checkIfMatch(w, r, existing.Version)
+	if !ok {
+		log.Printf("WarehouseHandler.Patch: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	Warehouse, ok := applyPatch(w, r, "warehouses", existing)
+	if !ok {
+		log.Printf("WarehouseHandler.Patch: invalid patch, duration: %v", time.Since(start))
+		return
+	}
+	Warehouse.ID = id
+	Warehouse.Version = version
+
+	updatedWarehouse, err := h.WarehouseService.UpdateWarehouse(Warehouse)
+	if writeVersionConflict(w, err) {
+		log.Printf("WarehouseHandler.Patch: version conflict, duration: %v", time.Since(start))
+		return
+	}
+	if err != nil {
+		log.Printf("WarehouseHandler.Patch: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(updatedWarehouse.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(updatedWarehouse); err != nil {
+		log.Printf("WarehouseHandler.Patch: encoding error: %v, duration: %v", err, time.Since(start))
+		return
+	}
+
+	log.Printf("WarehouseHandler.Patch: success, duration: %v", time.Since(start))
+}
+
+func (h *WarehouseHandler) DeleteWarehouseById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	start := time.Now()
+
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		log.Printf("WarehouseHandler.Delete: invalid id error: %v, duration: %v", err, time.Since(start))
+		writeError(w, http.StatusBadRequest, "invalid_id", "invalid warehouse id")
+		return
+	}
+
+	existing, err := h.WarehouseService.GetWarehouse(id)
+	if err != nil {
+		log.Printf("WarehouseHandler.Delete: not found error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+	if writeDeletePlan(w, r, "warehouses", id) {
+		log.Printf("WarehouseHandler.Delete: dry run, duration: %v", time.Since(start))
+		return
+	}
+	if _, ok := checkIfMatch(w, r, existing.Version); !ok {
+		log.Printf("WarehouseHandler.Delete: precondition failed, duration: %v", time.Since(start))
+		return
+	}
+
+	err = h.WarehouseService.DeleteWarehouse(id)
+	if err != nil {
+		log.Printf("WarehouseHandler.Delete: service error: %v, duration: %v", err, time.Since(start))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("WarehouseHandler.Delete: success, duration: %v", time.Since(start))
+}
@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of new keys between two removals of the expired keys
+const sweepEvery = 256
+
+// MemoryStore keeps the keys in memory, they only hold for a single instance
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+	calls   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+func (s *MemoryStore) Begin(key, fingerprint string, now time.Time, ttl time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls%sweepEvery == 0 {
+		for k, record := range s.records {
+			if now.After(record.ExpiresAt) {
+				delete(s.records, k)
+			}
+		}
+	}
+
+	if record, exists := s.records[key]; exists && now.Before(record.ExpiresAt) {
+		if !record.Done && record.Fingerprint == fingerprint {
+			return Record{}, false, ErrInProgress
+		}
+		return record, false, nil
+	}
+	record := Record{Fingerprint: fingerprint, ExpiresAt: now.Add(ttl)}
+	s.records[key] = record
+	return record, true, nil
+}
+
+func (s *MemoryStore) Complete(key string, response Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.records[key]
+	record.Done = true
+	record.Response = response
+	s.records[key] = record
+	return nil
+}
+
+func (s *MemoryStore) Abort(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrInProgress is returned when a request with the same key has not finished yet
+var ErrInProgress = errors.New("a request with this idempotency key is in progress")
+
+// Response is the recorded response of a request, replayed on its retries
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the state of an idempotency key, Done once the response is recorded
+type Record struct {
+	Fingerprint string
+	Done        bool
+	Response    Response
+	ExpiresAt   time.Time
+}
+
+// Store keeps the idempotency keys for their window, an implementation backed by a shared
+// store lets several instances of the server replay the same responses
+type Store interface {
+	// Begin claims a key for a new request and returns true, or returns the record of
+	// the key when it is already known. ErrInProgress reports a request still running.
+	Begin(key, fingerprint string, now time.Time, ttl time.Duration) (Record, bool, error)
+	// Complete records the response of the request that claimed the key
+	Complete(key string, response Response) error
+	// Abort releases a key so the request can be retried
+	Abort(key string) error
+}
+
+// TTLFromEnv reads how long the keys are kept from IDEMPOTENCY_TTL (a duration), 24 hours by default
+func TTLFromEnv() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL"))
+	if err != nil || ttl <= 0 {
+		return 24 * time.Hour
+	}
+	return ttl
+}
@@ -0,0 +1,177 @@
+// Package integrity enforces the references between the entities when one is deleted. Each
+// relation from the entities of a child resource to a parent resource has a policy: restrict
+// refuses to delete a referenced parent, cascade deletes the children with it and set-null
+// clears their reference. A SQL backend declares the same relations as foreign keys with
+// ON DELETE RESTRICT, CASCADE and SET NULL.
+package integrity
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"bookstore.com/repositories"
+)
+
+// Policy is what happens to the children of a deleted parent
+type Policy string
+
+// Delete policies
+const (
+	Restrict Policy = "restrict"
+	Cascade  Policy = "cascade"
+	SetNull  Policy = "set_null"
+)
+
+// Relation is the reference of the Field of the Child entities to a Parent entity
+type Relation struct {
+	Parent string
+	Child  string
+	Field  string
+	Policy Policy
+	// Children returns the ids of the children referencing a parent
+	Children func(parentID int) ([]int, error)
+	// Apply deletes a child (cascade) or clears its reference (set-null)
+	Apply func(childID int) error
+}
+
+// Effect is a child entity affected by a delete
+type Effect struct {
+	Resource string `json:"resource"`
+	ID       int    `json:"id"`
+	Field    string `json:"field"`
+	Policy   Policy `json:"policy"`
+}
+
+// Plan describes what deleting an entity does: the references Blocking it, the children
+// Deleted with it (the deepest first) and the children whose reference is Cleared
+type Plan struct {
+	Resource string   `json:"resource"`
+	ID       int      `json:"id"`
+	Allowed  bool     `json:"allowed"`
+	Blocking []Effect `json:"blocking"`
+	Deleted  []Effect `json:"deleted"`
+	Cleared  []Effect `json:"cleared"`
+}
+
+// ReferencedError is returned when restricting references block a delete
+type ReferencedError struct {
+	Plan Plan
+}
+
+func (e *ReferencedError) Error() string {
+	references := make([]string, len(e.Plan.Blocking))
+	for i, effect := range e.Plan.Blocking {
+		references[i] = fmt.Sprintf("%s %d (%s)", effect.Resource, effect.ID, effect.Field)
+	}
+	return fmt.Sprintf("%s %d is still referenced by %s", e.Plan.Resource, e.Plan.ID, strings.Join(references, ", "))
+}
+
+func (e *ReferencedError) Unwrap() error {
+	return repositories.ErrConflict
+}
+
+var (
+	// mu serializes the deletes, the writes adding references share it
+	mu        sync.RWMutex
+	relations []Relation
+)
+
+// Register adds relations, usually once at startup
+func Register(relation ...Relation) {
+	mu.Lock()
+	defer mu.Unlock()
+	relations = append(relations, relation...)
+}
+
+// Reference holds the deletes back until the returned function is called. The writes adding
+// references, e.g. creating a book of an author, check the referenced entities and write
+// under it so none is deleted in between.
+func Reference() (release func()) {
+	mu.RLock()
+	return mu.RUnlock
+}
+
+// PlanDelete returns what deleting an entity would do without changing anything
+func PlanDelete(resource string, id int) (Plan, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	plan, _, err := planDelete(resource, id)
+	return plan, err
+}
+
+// Delete deletes an entity with the given function once its relations allow it: the
+// children set to null are cleared and the cascading children deleted first. Deletes are
+// serialized and wait for the writes holding Reference, so no reference appears between the
+// check and the delete.
+func Delete(resource string, id int, delete func() error) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	plan, steps, err := planDelete(resource, id)
+	if err != nil {
+		return err
+	}
+	if !plan.Allowed {
+		return &ReferencedError{Plan: plan}
+	}
+	for _, step := range steps {
+		if err := step.relation.Apply(step.childID); err != nil {
+			return fmt.Errorf("%s %d: %w", step.relation.Child, step.childID, err)
+		}
+	}
+	return delete()
+}
+
+// step applies the policy of a relation to a child
+type step struct {
+	relation Relation
+	childID  int
+}
+
+func planDelete(resource string, id int) (Plan, []step, error) {
+	plan := Plan{Resource: resource, ID: id, Blocking: []Effect{}, Deleted: []Effect{}, Cleared: []Effect{}}
+	var steps []step
+	visited := map[string]bool{}
+
+	var walk func(resource string, id int) error
+	walk = func(resource string, id int) error {
+		key := fmt.Sprintf("%s/%d", resource, id)
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+		for _, relation := range relations {
+			if relation.Parent != resource {
+				continue
+			}
+			children, err := relation.Children(id)
+			if err != nil {
+				return err
+			}
+			for _, childID := range children {
+				effect := Effect{Resource: relation.Child, ID: childID, Field: relation.Field, Policy: relation.Policy}
+				switch relation.Policy {
+				case Restrict:
+					plan.Blocking = append(plan.Blocking, effect)
+				case SetNull:
+					plan.Cleared = append(plan.Cleared, effect)
+					steps = append(steps, step{relation: relation, childID: childID})
+				case Cascade:
+					if err := walk(relation.Child, childID); err != nil {
+						return err
+					}
+					plan.Deleted = append(plan.Deleted, effect)
+					steps = append(steps, step{relation: relation, childID: childID})
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(resource, id); err != nil {
+		return Plan{}, nil, err
+	}
+	plan.Allowed = len(plan.Blocking) == 0
+	return plan, steps, nil
+}
@@ -4,11 +4,19 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
+	"bookstore.com/auth"
 	"bookstore.com/handlers"
+	"bookstore.com/idempotency"
 	"bookstore.com/memory"
+	"bookstore.com/models"
+	"bookstore.com/notifications"
+	"bookstore.com/ratelimit"
 	"bookstore.com/services"
+	"bookstore.com/shape"
+	"bookstore.com/shipping"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -46,24 +54,101 @@ func DispatcherWrapper(w http.ResponseWriter, r *http.Request, ps httprouter.Par
 	}
 }
 
+// allocationStrategy reads how orders are allocated to the warehouses from
+// ORDER_ALLOCATION_STRATEGY (proximity or availability), proximity by default
+func allocationStrategy() string {
+	if os.Getenv("ORDER_ALLOCATION_STRATEGY") == models.AllocateByAvailability {
+		return models.AllocateByAvailability
+	}
+	return models.AllocateByProximity
+}
+
 func main() {
 	// Initialize the book service with the in-memory store
-	bookHandler := handlers.NewBookHandler(services.NewBookService(&database.BookStore))
-	authorHandler := handlers.NewAuthorHandler(services.NewAuthorService(&database.AuthorStore))
-	customerHandler := handlers.NewCustomerHandler(services.NewCustomerService(&database.CustomerStore))
-	orderHandler := handlers.NewOrderHandler(services.NewOrderService(&database.OrderStore))
+	inventoryService := services.NewInventoryService(&database.StockLedger, &database.BookStore, &database.StockLevels, &database.Warehouses, allocationStrategy())
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	references := services.NewReferences(&database.AuthorStore, &database.BookStore, &database.CustomerStore)
+	bookService := services.NewBookService(&database.BookStore, &database.AuthorStore, inventoryService, references)
+	bookHandler := handlers.NewBookHandler(bookService)
+	authorService := services.NewAuthorService(&database.AuthorStore)
+	authorHandler := handlers.NewAuthorHandler(authorService)
+	// the search index follows the changes of the books and of their authors
+	catalogSearchService := services.NewCatalogSearchService(&database.BookStore, &database.AuthorStore, references)
+	bookService.OnChange(catalogSearchService.IndexBook)
+	authorService.OnChange(catalogSearchService.IndexAuthorBooks)
+	searchHandler := handlers.NewSearchHandler(catalogSearchService)
+	// the suggestions follow the books and authors too, and the sales for their popularity
+	bookSaleService := services.NewBookSaleService(memory.NewInMemoryBookSaleStore(), &database.BookStore, references)
+	suggestService := services.NewSuggestService(&database.BookStore, &database.AuthorStore, memory.NewInMemoryBookSaleStore())
+	bookService.OnChange(suggestService.IndexBook)
+	authorService.OnChange(suggestService.IndexAuthor)
+	bookSaleService.OnChange(suggestService.SalesChanged)
+	suggestHandler := handlers.NewSuggestHandler(suggestService)
+	customerService := services.NewCustomerService(&database.CustomerStore)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	fulfillmentService := services.NewFulfillmentService(&database.OrderStore, &database.CustomerStore, &database.Purchases, &database.BookStore, inventoryService, references, services.DefaultFulfillmentConfig)
+	fulfillmentHandler := handlers.NewFulfillmentHandler(fulfillmentService)
+	shipmentService := services.NewShipmentService(&database.Shipments, &database.OrderStore, &database.Warehouses, &database.CustomerStore, time.Minute, shipping.NewCarrierFromEnv())
+	shipmentHandler := handlers.NewShipmentHandler(shipmentService)
+	orderService := services.NewOrderService(&database.OrderStore, &database.CustomerStore, &database.BookStore, fulfillmentService, references)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	authService := services.NewAuthService(&database.Accounts, &database.AuthTokens, &database.CustomerStore, notifications.NewSinkFromEnv(), services.DefaultAuthConfig)
+	authHandler := handlers.NewAuthHandler(authService, customerService, orderService)
+	jwtVerifier, err := auth.NewJWTVerifierFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if jwtVerifier == nil {
+		log.Println("No JWT key configured (JWT_HS256_SECRET, JWT_RS256_PUBLIC_KEY), only customer sessions are accepted")
+	}
+	apiKeyService := services.NewAPIKeyService(&database.APIKeys, routePermissions.Scopes())
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	authMiddleware := &handlers.AuthMiddleware{Sessions: authService, JWT: jwtVerifier, APIKeys: apiKeyService, Permissions: routePermissions}
+	rateLimitMiddleware := &handlers.RateLimitMiddleware{Limiter: ratelimit.NewLimiterFromEnv(), Policy: routeRateLimits}
+	idempotencyMiddleware := &handlers.IdempotencyMiddleware{Store: idempotency.NewMemoryStore(), TTL: idempotency.TTLFromEnv()}
+	salesReportService := services.NewSalesReportService(&database.SalesReport, &database.OrderStore, references)
+	salesReportHandler := handlers.NewSalesReportHandler(salesReportService)
+	bookSaleHandler := handlers.NewBookSaleHandler(bookSaleService)
+	reorderService := services.NewReorderService(&database.BookStore, memory.NewInMemoryBookSaleStore(), notifications.NewSinkFromEnv(), services.DefaultReorderConfig)
+	reorderHandler := handlers.NewReorderHandler(reorderService)
+	supplierHandler := handlers.NewSupplierHandler(services.NewSupplierService(&database.Suppliers))
+	warehouseHandler := handlers.NewWarehouseHandler(services.NewWarehouseService(&database.Warehouses, &database.StockLevels, inventoryService))
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(services.NewPurchaseOrderService(&database.Purchases, &database.Suppliers, &database.BookStore, inventoryService, references))
+	// PUT and DELETE need the ETag of the entity in If-Match unless IF_MATCH_REQUIRED=false
+	handlers.RequireIfMatch = os.Getenv("IF_MATCH_REQUIRED") != "false"
+	handlers.FieldPermissions = fieldPermissions
+	// ?expand crosses at most EXPAND_MAX_DEPTH references, 2 by default
+	handlers.ExpandMaxDepth = shape.MaxDepthFromEnv()
+	registerRelations(database)
 	// Set up router
 	router := httprouter.New()
+	router.NotFound = http.HandlerFunc(handlers.RouteNotFound)
+	router.MethodNotAllowed = http.HandlerFunc(handlers.MethodNotAllowed)
 	handleBookRequests(router, bookHandler)
-	handleAuthorRequests(router, authorHandler)
-	handleCustomerRequests(router, customerHandler)
+	handleSearchRequests(router, searchHandler, suggestHandler)
+	handleAuthorRequests(router, authorHandler, bookHandler)
+	handleCustomerRequests(router, customerHandler, orderHandler)
 	handleOrderRequests(router, orderHandler)
+	handleBackorderRequests(router, fulfillmentHandler)
+	handleShipmentRequests(router, shipmentHandler)
+	handleAuthRequests(router, authHandler)
+	handleAPIKeyRequests(router, apiKeyHandler)
+	handleSalesReportRequests(router, salesReportHandler)
+	handleBookSaleRequests(router, bookSaleHandler)
+	handleInventoryRequests(router, inventoryHandler, reorderHandler, purchaseOrderHandler)
+	handleSupplierRequests(router, supplierHandler, purchaseOrderHandler)
+	handlePurchaseOrderRequests(router, purchaseOrderHandler)
+	handleWarehouseRequests(router, warehouseHandler, inventoryHandler)
 
 	//database.Schedule()
+	services.NewReportScheduler(salesReportService, services.DefaultReportSchedules).Start()
+	reorderService.Start()
+	fulfillmentService.Start()
+	shipmentService.Start()
 
 	// Start the HTTP server
 	log.Println("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	log.Fatal(http.ListenAndServe(":8080", authMiddleware.Handler(rateLimitMiddleware.Handler(idempotencyMiddleware.Handler(router)))))
 }
 
 func handleBookRequests(router *httprouter.Router, bookHandler *handlers.BookHandler) {
@@ -79,13 +164,25 @@ func handleBookRequests(router *httprouter.Router, bookHandler *handlers.BookHan
 	router.PUT("/books/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, bookHandler.UpdateBookById)
 	})
+	router.PATCH("/books/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, bookHandler.PatchBookById)
+	})
 	router.DELETE("/books/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, bookHandler.DeleteBookById)
 	})
 
 }
 
-func handleAuthorRequests(router *httprouter.Router, authorHandler *handlers.AuthorHandler) {
+func handleSearchRequests(router *httprouter.Router, searchHandler *handlers.SearchHandler, suggestHandler *handlers.SuggestHandler) {
+	router.GET("/search", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, searchHandler.Search)
+	})
+	router.GET("/suggest", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, suggestHandler.Suggest)
+	})
+}
+
+func handleAuthorRequests(router *httprouter.Router, authorHandler *handlers.AuthorHandler, bookHandler *handlers.BookHandler) {
 	router.POST("/authors", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, authorHandler.CreateAuthor)
 	})
@@ -98,13 +195,19 @@ func handleAuthorRequests(router *httprouter.Router, authorHandler *handlers.Aut
 	router.PUT("/authors/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, authorHandler.UpdateAuthorById)
 	})
+	router.PATCH("/authors/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authorHandler.PatchAuthorById)
+	})
 	router.DELETE("/authors/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, authorHandler.DeleteAuthorById)
 	})
+	router.GET("/authors/:id/books", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, bookHandler.GetAuthorBooks)
+	})
 
 }
 
-func handleCustomerRequests(router *httprouter.Router, customerHandler *handlers.CustomerHandler) {
+func handleCustomerRequests(router *httprouter.Router, customerHandler *handlers.CustomerHandler, orderHandler *handlers.OrderHandler) {
 	router.POST("/customers", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, customerHandler.CreateCustomer)
 	})
@@ -117,9 +220,15 @@ func handleCustomerRequests(router *httprouter.Router, customerHandler *handlers
 	router.PUT("/customers/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, customerHandler.UpdateCustomerById)
 	})
+	router.PATCH("/customers/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, customerHandler.PatchCustomerById)
+	})
 	router.DELETE("/customers/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, customerHandler.DeleteCustomerById)
 	})
+	router.GET("/customers/:id/orders", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, orderHandler.GetCustomerOrders)
+	})
 
 }
 func handleOrderRequests(router *httprouter.Router, orderHandler *handlers.OrderHandler) {
@@ -135,8 +244,237 @@ func handleOrderRequests(router *httprouter.Router, orderHandler *handlers.Order
 	router.PUT("/orders/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, orderHandler.UpdateOrderById)
 	})
+	router.PATCH("/orders/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, orderHandler.PatchOrderById)
+	})
 	router.DELETE("/orders/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		DispatcherWrapper(w, r, ps, orderHandler.DeleteOrderById)
 	})
+	router.GET("/orders/:id/items", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, orderHandler.GetOrderItems)
+	})
+	router.POST("/orders/:id/items", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, orderHandler.AddOrderItem)
+	})
+
+}
+
+func handleBackorderRequests(router *httprouter.Router, fulfillmentHandler *handlers.FulfillmentHandler) {
+	router.GET("/backorders", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, fulfillmentHandler.GetBackorders)
+	})
+	router.POST("/backorders/fulfill", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, fulfillmentHandler.FulfillBackorders)
+	})
+
+}
+
+func handleShipmentRequests(router *httprouter.Router, shipmentHandler *handlers.ShipmentHandler) {
+	router.POST("/orders/:id/shipments", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, shipmentHandler.CreateShipment)
+	})
+	router.GET("/orders/:id/shipments", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, shipmentHandler.GetOrderShipments)
+	})
+	router.GET("/shipments/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, shipmentHandler.GetShipmentById)
+	})
+	router.GET("/shipments", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, shipmentHandler.GetShipmentsByCriteria)
+	})
+	router.POST("/shipments/:id/track", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, shipmentHandler.TrackShipmentById)
+	})
+
+}
+
+func handleAuthRequests(router *httprouter.Router, authHandler *handlers.AuthHandler) {
+	router.POST("/auth/register", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.Register)
+	})
+	router.POST("/auth/login", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.Login)
+	})
+	router.POST("/auth/logout", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.Logout)
+	})
+	router.POST("/auth/password-reset", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.RequestPasswordReset)
+	})
+	router.POST("/auth/password-reset/confirm", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.ResetPassword)
+	})
+	router.GET("/account", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.GetAccount)
+	})
+	router.PUT("/account", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.UpdateAccount)
+	})
+	router.GET("/account/orders", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, authHandler.GetAccountOrders)
+	})
+
+}
+
+func handleAPIKeyRequests(router *httprouter.Router, apiKeyHandler *handlers.APIKeyHandler) {
+	router.POST("/apiKeys", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, apiKeyHandler.CreateAPIKey)
+	})
+	router.GET("/apiKeys/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, apiKeyHandler.GetAPIKeyById)
+	})
+	router.GET("/apiKeys", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, apiKeyHandler.GetAPIKeysByCriteria)
+	})
+	router.POST("/apiKeys/:id/rotate", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, apiKeyHandler.RotateAPIKey)
+	})
+	router.DELETE("/apiKeys/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, apiKeyHandler.RevokeAPIKey)
+	})
+
+}
+
+func handleSalesReportRequests(router *httprouter.Router, salesReportHandler *handlers.SalesReportHandler) {
+	router.POST("/reports", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, salesReportHandler.CreateReport)
+	})
+	router.GET("/reports/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, salesReportHandler.GetReportById)
+	})
+	router.GET("/reports/:id/comparison", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, salesReportHandler.CompareReportById)
+	})
+	router.GET("/reports", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, salesReportHandler.GetReportsByCriteria)
+	})
+	router.DELETE("/reports/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, salesReportHandler.DeleteReportById)
+	})
+
+}
+
+func handleBookSaleRequests(router *httprouter.Router, bookSaleHandler *handlers.BookSaleHandler) {
+	router.POST("/bookSales", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, bookSaleHandler.CreateBookSale)
+	})
+	router.GET("/bookSales/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, bookSaleHandler.GetBookSaleById)
+	})
+	router.GET("/bookSales", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, bookSaleHandler.GetBookSalesByCriteria)
+	})
+	router.DELETE("/bookSales/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, bookSaleHandler.DeleteBookSaleById)
+	})
+	router.GET("/salesReport", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, bookSaleHandler.GenerateReports)
+	})
+
+}
+
+func handleInventoryRequests(router *httprouter.Router, inventoryHandler *handlers.InventoryHandler, reorderHandler *handlers.ReorderHandler, purchaseOrderHandler *handlers.PurchaseOrderHandler) {
+	router.POST("/inventory/movements", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, inventoryHandler.CreateMovement)
+	})
+	router.GET("/inventory/movements/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, inventoryHandler.GetMovementById)
+	})
+	router.GET("/inventory/books/:id/movements", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, inventoryHandler.GetBookMovements)
+	})
+	router.GET("/inventory/books/:id/stock", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, inventoryHandler.GetBookStockLevels)
+	})
+	router.POST("/inventory/transfers", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, inventoryHandler.CreateTransfer)
+	})
+	router.GET("/inventory/valuation", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, inventoryHandler.GetValuation)
+	})
+	router.GET("/inventory/reorder-suggestions", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, reorderHandler.GetReorderSuggestions)
+	})
+	router.GET("/inventory/margins", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.GetMargins)
+	})
+
+}
+
+func handleSupplierRequests(router *httprouter.Router, supplierHandler *handlers.SupplierHandler, purchaseOrderHandler *handlers.PurchaseOrderHandler) {
+	router.POST("/suppliers", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, supplierHandler.CreateSupplier)
+	})
+	router.GET("/suppliers/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, supplierHandler.GetSupplierById)
+	})
+	router.GET("/suppliers/:id/costs", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.GetSupplierCosts)
+	})
+	router.GET("/suppliers", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, supplierHandler.GetSuppliersByCriteria)
+	})
+	router.PUT("/suppliers/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, supplierHandler.UpdateSupplierById)
+	})
+	router.PATCH("/suppliers/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, supplierHandler.PatchSupplierById)
+	})
+	router.DELETE("/suppliers/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, supplierHandler.DeleteSupplierById)
+	})
+
+}
+
+func handlePurchaseOrderRequests(router *httprouter.Router, purchaseOrderHandler *handlers.PurchaseOrderHandler) {
+	router.POST("/purchaseOrders", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.CreatePurchaseOrder)
+	})
+	router.GET("/purchaseOrders/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.GetPurchaseOrderById)
+	})
+	router.GET("/purchaseOrders", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.GetPurchaseOrdersByCriteria)
+	})
+	router.PUT("/purchaseOrders/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.UpdatePurchaseOrderById)
+	})
+	router.PATCH("/purchaseOrders/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.PatchPurchaseOrderById)
+	})
+	router.DELETE("/purchaseOrders/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.DeletePurchaseOrderById)
+	})
+	router.POST("/purchaseOrders/:id/send", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.SendPurchaseOrderById)
+	})
+	router.POST("/purchaseOrders/:id/receive", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, purchaseOrderHandler.ReceivePurchaseOrderById)
+	})
+
+}
+
+func handleWarehouseRequests(router *httprouter.Router, warehouseHandler *handlers.WarehouseHandler, inventoryHandler *handlers.InventoryHandler) {
+	router.POST("/warehouses", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, warehouseHandler.CreateWarehouse)
+	})
+	router.GET("/warehouses/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, warehouseHandler.GetWarehouseById)
+	})
+	router.GET("/warehouses/:id/stock", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, inventoryHandler.GetWarehouseStock)
+	})
+	router.GET("/warehouses", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, warehouseHandler.GetWarehousesByCriteria)
+	})
+	router.PUT("/warehouses/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, warehouseHandler.UpdateWarehouseById)
+	})
+	router.PATCH("/warehouses/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, warehouseHandler.PatchWarehouseById)
+	})
+	router.DELETE("/warehouses/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		DispatcherWrapper(w, r, ps, warehouseHandler.DeleteWarehouseById)
+	})
 
 }
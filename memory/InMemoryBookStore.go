@@ -1,18 +1,21 @@
 package memory
 
 import (
-	"errors"
-	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
 	"bookstore.com/models"
+	"bookstore.com/repositories"
 )
 
 type InMemoryBookStore struct {
 	mu     sync.Mutex
 	Books  map[int]models.Book
 	nextID int
+
+	byAuthor *hashIndex[models.Book, int]
+	byGenre  *hashIndex[models.Book, string]
 }
 
 var (
@@ -28,6 +31,7 @@ func NewInMemoryBookStore() *InMemoryBookStore {
 			Books:  make(map[int]models.Book),
 			nextID: 1,
 		}
+		bookStoreInstance.buildIndexes()
 	})
 	return bookStoreInstance
 }
@@ -37,9 +41,11 @@ func (s *InMemoryBookStore) Create(book models.Book) (models.Book, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	book = bookOnly(book)
 	book.ID = s.nextID
+	book.Version = 1
 	s.Books[s.nextID] = book
-	fmt.Println(s.Books)
+	s.index(book)
 	s.nextID++
 	return book, nil
 }
@@ -51,7 +57,7 @@ func (s *InMemoryBookStore) Get(id int) (models.Book, error) {
 
 	book, exists := s.Books[id]
 	if !exists {
-		return models.Book{}, errors.New("book not found")
+		return models.Book{}, repositories.NotFound("book", id)
 	}
 	return book, nil
 }
@@ -61,11 +67,18 @@ func (s *InMemoryBookStore) Update(book models.Book) (models.Book, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.Books[book.ID]
+	stored, exists := s.Books[book.ID]
 	if !exists {
-		return models.Book{}, errors.New("book not found")
+		return models.Book{}, repositories.NotFound("book", book.ID)
+	}
+	if book.Version != 0 && book.Version != stored.Version {
+		return models.Book{}, repositories.ErrVersionConflict
 	}
+	book = bookOnly(book)
+	book.Version = stored.Version + 1
 	s.Books[book.ID] = book
+	s.unindex(stored)
+	s.index(book)
 	return book, nil
 }
 
@@ -74,16 +87,20 @@ func (s *InMemoryBookStore) Delete(id int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.Books[id]
+	stored, exists := s.Books[id]
 	if !exists {
-		return errors.New("book not found")
+		return repositories.NotFound("book", id)
 	}
 	delete(s.Books, id)
+	s.unindex(stored)
 	return nil
 }
 
 // Search filters books based on the search criteria
 func (s *InMemoryBookStore) Search(query models.SearchCriteria) ([]models.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var results []models.Book
 	if len(query.Filters) == 0 {
 		for _, book := range s.Books {
@@ -91,7 +108,14 @@ func (s *InMemoryBookStore) Search(query models.SearchCriteria) ([]models.Book,
 		}
 		return results, nil
 	}
-	for _, book := range s.Books {
+	books := s.Books
+	if candidates, indexed := s.candidates(query.Filters); indexed {
+		books = make(map[int]models.Book, len(candidates))
+		for id := range candidates {
+			books[id] = s.Books[id]
+		}
+	}
+	for _, book := range books {
 		match := true
 
 		if title, exists := query.Filters["title"]; exists {
@@ -100,8 +124,14 @@ func (s *InMemoryBookStore) Search(query models.SearchCriteria) ([]models.Book,
 			}
 		}
 
-		if author, exists := query.Filters["author"]; exists {
-			if !strings.Contains(book.Author.FirstName, author.(string)) {
+		if authors, exists := query.Filters["author_id"]; exists {
+			if ids, ok := authors.([]int); ok {
+				if !slices.Contains(ids, book.Author.ID) {
+					match = false
+				}
+			} else if id, err := filterInt(authors); err != nil {
+				return nil, err
+			} else if book.Author.ID != id {
 				match = false
 			}
 		}
@@ -125,6 +155,39 @@ func (s *InMemoryBookStore) Search(query models.SearchCriteria) ([]models.Book,
 			}
 		}
 
+		// the price ranges of the facets: min_price included, max_price excluded
+		if minPrice, exists := query.Filters["min_price"]; exists {
+			if value, err := filterFloat(minPrice); err != nil {
+				return nil, err
+			} else if book.Price < value {
+				match = false
+			}
+		}
+
+		if maxPrice, exists := query.Filters["max_price"]; exists {
+			if value, err := filterFloat(maxPrice); err != nil {
+				return nil, err
+			} else if book.Price >= value {
+				match = false
+			}
+		}
+
+		if year, exists := query.Filters["year"]; exists {
+			if value, err := filterInt(year); err != nil {
+				return nil, err
+			} else if book.PublishedAt.IsZero() || book.PublishedAt.Year() != value {
+				match = false
+			}
+		}
+
+		if inStock, exists := query.Filters["in_stock"]; exists {
+			if value, err := filterBool(inStock); err != nil {
+				return nil, err
+			} else if (book.Stock > 0) != value {
+				match = false
+			}
+		}
+
 		if match {
 			results = append(results, book)
 		}
@@ -132,3 +195,40 @@ func (s *InMemoryBookStore) Search(query models.SearchCriteria) ([]models.Book,
 
 	return results, nil
 }
+
+// buildIndexes indexes the books by author and by genre
+func (s *InMemoryBookStore) buildIndexes() {
+	s.byAuthor = newHashIndex(func(book models.Book) []int { return []int{book.Author.ID} })
+	s.byGenre = newHashIndex(func(book models.Book) []string { return book.Genres })
+	for _, book := range s.Books {
+		s.index(book)
+	}
+}
+
+func (s *InMemoryBookStore) index(book models.Book) {
+	s.byAuthor.add(book.ID, book)
+	s.byGenre.add(book.ID, book)
+}
+
+func (s *InMemoryBookStore) unindex(book models.Book) {
+	s.byAuthor.remove(book.ID, book)
+	s.byGenre.remove(book.ID, book)
+}
+
+// candidates returns the books the author_id and genre filters may match, found with the
+// indexes, and whether a filter was indexed. The genre filter matches the genres containing it,
+// looked up among the distinct genres.
+func (s *InMemoryBookStore) candidates(filters map[string]interface{}) (map[int]bool, bool) {
+	var byAuthor, byGenre map[int]bool
+	if authors, exists := filters["author_id"]; exists {
+		if ids, ok := authors.([]int); ok {
+			byAuthor = s.byAuthor.lookup(ids...)
+		} else if id, err := filterInt(authors); err == nil {
+			byAuthor = s.byAuthor.lookup(id)
+		}
+	}
+	if genre, ok := filters["genre"].(string); ok {
+		byGenre = s.byGenre.lookupFunc(func(g string) bool { return strings.Contains(g, genre) })
+	}
+	return smallest(byAuthor, byGenre)
+}
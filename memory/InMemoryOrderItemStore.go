@@ -1,10 +1,10 @@
 package memory
 
 import (
-	"errors"
 	"sync"
 
 	"bookstore.com/models"
+	"bookstore.com/repositories"
 )
 
 type InMemoryOrderItemStore struct {
@@ -35,7 +35,9 @@ func (s *InMemoryOrderItemStore) Create(OrderItem models.OrderItem) (models.Orde
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	OrderItem = orderItemOnly(OrderItem)
 	OrderItem.ID = s.nextID
+	OrderItem.Version = 1
 	s.OrderItems[s.nextID] = OrderItem
 	s.nextID++
 	return OrderItem, nil
@@ -48,7 +50,7 @@ func (s *InMemoryOrderItemStore) Get(id int) (models.OrderItem, error) {
 
 	OrderItem, exists := s.OrderItems[id]
 	if !exists {
-		return models.OrderItem{}, errors.New("OrderItem not found")
+		return models.OrderItem{}, repositories.NotFound("order item", id)
 	}
 	return OrderItem, nil
 }
@@ -58,10 +60,15 @@ func (s *InMemoryOrderItemStore) Update(OrderItem models.OrderItem) (models.Orde
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.OrderItems[OrderItem.ID]
+	stored, exists := s.OrderItems[OrderItem.ID]
 	if !exists {
-		return models.OrderItem{}, errors.New("OrderItem not found")
+		return models.OrderItem{}, repositories.NotFound("order item", OrderItem.ID)
 	}
+	if OrderItem.Version != 0 && OrderItem.Version != stored.Version {
+		return models.OrderItem{}, repositories.ErrVersionConflict
+	}
+	OrderItem = orderItemOnly(OrderItem)
+	OrderItem.Version = stored.Version + 1
 	s.OrderItems[OrderItem.ID] = OrderItem
 	return OrderItem, nil
 }
@@ -73,7 +80,7 @@ func (s *InMemoryOrderItemStore) Delete(id int) error {
 
 	_, exists := s.OrderItems[id]
 	if !exists {
-		return errors.New("OrderItem not found")
+		return repositories.NotFound("order item", id)
 	}
 	delete(s.OrderItems, id)
 	return nil
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemoryAPIKeyStore struct {
+	mu      sync.Mutex
+	APIKeys map[int]models.APIKey
+	nextID  int
+}
+
+var (
+	apiKeyStoreInstance *InMemoryAPIKeyStore
+	apiKeyStoreOnce     sync.Once
+)
+
+// NewInMemoryAPIKeyStore returns the singleton instance of InMemoryAPIKeyStore
+func NewInMemoryAPIKeyStore() *InMemoryAPIKeyStore {
+	apiKeyStoreOnce.Do(func() {
+		apiKeyStoreInstance = &InMemoryAPIKeyStore{
+			APIKeys: make(map[int]models.APIKey),
+			nextID:  1,
+		}
+	})
+	return apiKeyStoreInstance
+}
+
+// Create adds a new API key to the store
+func (s *InMemoryAPIKeyStore) Create(key models.APIKey) (models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nextID == 0 {
+		for id := range s.APIKeys {
+			s.nextID = max(s.nextID, id)
+		}
+		s.nextID++
+	}
+	key.ID = s.nextID
+	key.Version = 1
+	s.APIKeys[s.nextID] = key
+	s.nextID++
+	return key, nil
+}
+
+// Get retrieves an API key by ID
+func (s *InMemoryAPIKeyStore) Get(id int) (models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.APIKeys[id]
+	if !exists {
+		return models.APIKey{}, repositories.NotFound("API key", id)
+	}
+	return key, nil
+}
+
+// Update modifies an existing API key in the store
+func (s *InMemoryAPIKeyStore) Update(key models.APIKey) (models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.APIKeys[key.ID]
+	if !exists {
+		return models.APIKey{}, repositories.NotFound("API key", key.ID)
+	}
+	if key.Version != 0 && key.Version != stored.Version {
+		return models.APIKey{}, repositories.ErrVersionConflict
+	}
+	key.Version = stored.Version + 1
+	s.APIKeys[key.ID] = key
+	return key, nil
+}
+
+// Search filters API keys by prefix and name
+func (s *InMemoryAPIKeyStore) Search(query models.SearchCriteria) ([]models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]models.APIKey, 0)
+	for _, key := range s.APIKeys {
+		match := true
+
+		if prefix, exists := query.Filters["prefix"]; exists {
+			if key.Prefix != prefix {
+				match = false
+			}
+		}
+
+		if name, exists := query.Filters["name"]; exists {
+			if key.Name != name {
+				match = false
+			}
+		}
+
+		if match {
+			results = append(results, key)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
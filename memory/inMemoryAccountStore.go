@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemoryAccountStore struct {
+	mu       sync.Mutex
+	Accounts map[int]models.Account
+	nextID   int
+
+	byEmail *hashIndex[models.Account, string]
+}
+
+var (
+	accountStoreInstance *InMemoryAccountStore
+	accountStoreOnce     sync.Once
+)
+
+// NewInMemoryAccountStore returns the singleton instance of InMemoryAccountStore
+func NewInMemoryAccountStore() *InMemoryAccountStore {
+	accountStoreOnce.Do(func() {
+		accountStoreInstance = &InMemoryAccountStore{
+			Accounts: make(map[int]models.Account),
+			nextID:   1,
+		}
+		accountStoreInstance.buildIndexes()
+	})
+	return accountStoreInstance
+}
+
+// Create adds a new account to the store
+func (s *InMemoryAccountStore) Create(account models.Account) (models.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nextID == 0 {
+		for id := range s.Accounts {
+			s.nextID = max(s.nextID, id)
+		}
+		s.nextID++
+	}
+	account.ID = s.nextID
+	account.Version = 1
+	s.Accounts[s.nextID] = account
+	s.byEmail.add(account.ID, account)
+	s.nextID++
+	return account, nil
+}
+
+// Get retrieves a account by ID
+func (s *InMemoryAccountStore) Get(id int) (models.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, exists := s.Accounts[id]
+	if !exists {
+		return models.Account{}, repositories.NotFound("account", id)
+	}
+	return account, nil
+}
+
+// Update modifies an existing account in the store
+func (s *InMemoryAccountStore) Update(account models.Account) (models.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.Accounts[account.ID]
+	if !exists {
+		return models.Account{}, repositories.NotFound("account", account.ID)
+	}
+	if account.Version != 0 && account.Version != stored.Version {
+		return models.Account{}, repositories.ErrVersionConflict
+	}
+	account.Version = stored.Version + 1
+	s.Accounts[account.ID] = account
+	s.byEmail.remove(stored.ID, stored)
+	s.byEmail.add(account.ID, account)
+	return account, nil
+}
+
+// Delete removes an account by ID
+func (s *InMemoryAccountStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.Accounts[id]
+	if !exists {
+		return repositories.NotFound("account", id)
+	}
+	delete(s.Accounts, id)
+	s.byEmail.remove(id, stored)
+	return nil
+}
+
+// Search filters accounts by email (case insensitive), found with the email index, and
+// customer_id
+func (s *InMemoryAccountStore) Search(query models.SearchCriteria) ([]models.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customerID := 0
+	if value, exists := query.Filters["customer_id"]; exists {
+		var err error
+		if customerID, err = filterInt(value); err != nil {
+			return nil, err
+		}
+	}
+
+	accounts := s.Accounts
+	if email, ok := query.Filters["email"].(string); ok {
+		accounts = make(map[int]models.Account)
+		for id := range s.byEmail.lookup(strings.ToLower(email)) {
+			accounts[id] = s.Accounts[id]
+		}
+	}
+	results := make([]models.Account, 0)
+	for _, account := range accounts {
+		match := true
+
+		if email, exists := query.Filters["email"]; exists {
+			if email, ok := email.(string); !ok || !strings.EqualFold(account.Email, email) {
+				match = false
+			}
+		}
+
+		if customerID != 0 && account.CustomerID != customerID {
+			match = false
+		}
+
+		if match {
+			results = append(results, account)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// buildIndexes indexes the accounts by email, lower-cased
+func (s *InMemoryAccountStore) buildIndexes() {
+	s.byEmail = newHashIndex(func(account models.Account) []string { return []string{strings.ToLower(account.Email)} })
+	for _, account := range s.Accounts {
+		s.byEmail.add(account.ID, account)
+	}
+}
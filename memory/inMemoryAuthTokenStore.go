@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemoryAuthTokenStore struct {
+	mu         sync.Mutex
+	AuthTokens map[int]models.AuthToken
+	nextID     int
+}
+
+var (
+	authTokenStoreInstance *InMemoryAuthTokenStore
+	authTokenStoreOnce     sync.Once
+)
+
+// NewInMemoryAuthTokenStore returns the singleton instance of InMemoryAuthTokenStore
+func NewInMemoryAuthTokenStore() *InMemoryAuthTokenStore {
+	authTokenStoreOnce.Do(func() {
+		authTokenStoreInstance = &InMemoryAuthTokenStore{
+			AuthTokens: make(map[int]models.AuthToken),
+			nextID:     1,
+		}
+	})
+	return authTokenStoreInstance
+}
+
+// Create adds a new token to the store
+func (s *InMemoryAuthTokenStore) Create(token models.AuthToken) (models.AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nextID == 0 {
+		for id := range s.AuthTokens {
+			s.nextID = max(s.nextID, id)
+		}
+		s.nextID++
+	}
+	token.ID = s.nextID
+	token.Version = 1
+	s.AuthTokens[s.nextID] = token
+	s.nextID++
+	return token, nil
+}
+
+// Get retrieves a token by ID
+func (s *InMemoryAuthTokenStore) Get(id int) (models.AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, exists := s.AuthTokens[id]
+	if !exists {
+		return models.AuthToken{}, repositories.NotFound("token", id)
+	}
+	return token, nil
+}
+
+// Update modifies an existing token in the store
+func (s *InMemoryAuthTokenStore) Update(token models.AuthToken) (models.AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.AuthTokens[token.ID]
+	if !exists {
+		return models.AuthToken{}, repositories.NotFound("token", token.ID)
+	}
+	if token.Version != 0 && token.Version != stored.Version {
+		return models.AuthToken{}, repositories.ErrVersionConflict
+	}
+	token.Version = stored.Version + 1
+	s.AuthTokens[token.ID] = token
+	return token, nil
+}
+
+// Delete removes a token by ID
+func (s *InMemoryAuthTokenStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.AuthTokens[id]
+	if !exists {
+		return repositories.NotFound("token", id)
+	}
+	delete(s.AuthTokens, id)
+	return nil
+}
+
+// Search filters tokens by token_hash, kind and customer_id
+func (s *InMemoryAuthTokenStore) Search(query models.SearchCriteria) ([]models.AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customerID := 0
+	if value, exists := query.Filters["customer_id"]; exists {
+		var err error
+		if customerID, err = filterInt(value); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]models.AuthToken, 0)
+	for _, token := range s.AuthTokens {
+		match := true
+
+		if tokenHash, exists := query.Filters["token_hash"]; exists {
+			if token.TokenHash != tokenHash {
+				match = false
+			}
+		}
+
+		if kind, exists := query.Filters["kind"]; exists {
+			if token.Kind != kind {
+				match = false
+			}
+		}
+
+		if customerID != 0 && token.CustomerID != customerID {
+			match = false
+		}
+
+		if match {
+			results = append(results, token)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
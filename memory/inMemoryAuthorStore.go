@@ -1,12 +1,11 @@
 package memory
 
 import (
-	"errors"
-	"fmt"
 	"strings"
 	"sync"
 
 	"bookstore.com/models"
+	"bookstore.com/repositories"
 )
 
 type InMemoryAuthorStore struct {
@@ -35,6 +34,7 @@ func (s *InMemoryAuthorStore) Create(Author models.Author) (models.Author, error
 	defer s.mu.Unlock()
 
 	Author.ID = s.nextID
+	Author.Version = 1
 	s.Authors[s.nextID] = Author
 	s.nextID++
 	return Author, nil
@@ -45,9 +45,8 @@ func (s *InMemoryAuthorStore) Get(id int) (models.Author, error) {
 	defer s.mu.Unlock()
 
 	Author, exists := s.Authors[id]
-	fmt.Println(s.Authors)
 	if !exists {
-		return models.Author{}, errors.New("Author not found")
+		return models.Author{}, repositories.NotFound("author", id)
 	}
 	return Author, nil
 }
@@ -56,10 +55,14 @@ func (s *InMemoryAuthorStore) Update(Author models.Author) (models.Author, error
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.Authors[Author.ID]
+	stored, exists := s.Authors[Author.ID]
 	if !exists {
-		return models.Author{}, errors.New("Author not found")
+		return models.Author{}, repositories.NotFound("author", Author.ID)
 	}
+	if Author.Version != 0 && Author.Version != stored.Version {
+		return models.Author{}, repositories.ErrVersionConflict
+	}
+	Author.Version = stored.Version + 1
 	s.Authors[Author.ID] = Author
 	return Author, nil
 }
@@ -70,13 +73,16 @@ func (s *InMemoryAuthorStore) Delete(id int) error {
 
 	_, exists := s.Authors[id]
 	if !exists {
-		return errors.New("Author not found")
+		return repositories.NotFound("author", id)
 	}
 	delete(s.Authors, id)
 	return nil
 }
 
 func (s *InMemoryAuthorStore) Search(query models.SearchCriteria) ([]models.Author, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var results []models.Author
 	if len(query.Filters) == 0 {
 		for _, book := range s.Authors {
@@ -1,11 +1,11 @@
 package memory
 
 import (
-	"errors"
-	"strings"
+	"slices"
 	"sync"
 
 	"bookstore.com/models"
+	"bookstore.com/repositories"
 )
 
 type InMemoryBookSaleStore struct {
@@ -35,7 +35,9 @@ func (s *InMemoryBookSaleStore) Create(bookSale models.BookSale) (models.BookSal
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	bookSale = bookSaleOnly(bookSale)
 	bookSale.ID = s.nextID
+	bookSale.Version = 1
 	s.bookSales[s.nextID] = bookSale
 	s.nextID++
 	return bookSale, nil
@@ -48,7 +50,7 @@ func (s *InMemoryBookSaleStore) Get(id int) (models.BookSale, error) {
 
 	bookSale, exists := s.bookSales[id]
 	if !exists {
-		return models.BookSale{}, errors.New("BookSale not found")
+		return models.BookSale{}, repositories.NotFound("book sale", id)
 	}
 	return bookSale, nil
 }
@@ -57,10 +59,15 @@ func (s *InMemoryBookSaleStore) Update(bookSale models.BookSale) (models.BookSal
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.bookSales[bookSale.ID]
+	stored, exists := s.bookSales[bookSale.ID]
 	if !exists {
-		return models.BookSale{}, errors.New("BookSale not found")
+		return models.BookSale{}, repositories.NotFound("book sale", bookSale.ID)
 	}
+	if bookSale.Version != 0 && bookSale.Version != stored.Version {
+		return models.BookSale{}, repositories.ErrVersionConflict
+	}
+	bookSale = bookSaleOnly(bookSale)
+	bookSale.Version = stored.Version + 1
 	s.bookSales[bookSale.ID] = bookSale
 	return bookSale, nil
 }
@@ -71,13 +78,16 @@ func (s *InMemoryBookSaleStore) Delete(id int) error {
 
 	_, exists := s.bookSales[id]
 	if !exists {
-		return errors.New("BookSale not found")
+		return repositories.NotFound("book sale", id)
 	}
 	delete(s.bookSales, id)
 	return nil
 }
 
 func (s *InMemoryBookSaleStore) Search(query models.SearchCriteria) ([]models.BookSale, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var results []models.BookSale
 	if len(query.Filters) == 0 {
 		for _, bookSale := range s.bookSales {
@@ -89,30 +99,16 @@ func (s *InMemoryBookSaleStore) Search(query models.SearchCriteria) ([]models.Bo
 	for _, bookSale := range s.bookSales {
 		match := true
 
-		// Filter by title
-		if title, exists := query.Filters["title"]; exists {
-			if !strings.Contains(bookSale.Book.Title, title.(string)) {
-				match = false
-			}
-		}
-
-		// Filter by author (assuming you want the author's first name)
-		if author, exists := query.Filters["author"]; exists {
-			if !strings.Contains(bookSale.Book.Author.FirstName, author.(string)) {
-				match = false
-			}
-		}
-
-		// Filter by genre
-		if genre, exists := query.Filters["genre"]; exists {
-			genreMatch := false
-			for _, g := range bookSale.Book.Genres {
-				if strings.Contains(g, genre.(string)) {
-					genreMatch = true
-					break
+		// Filter by book, the service resolves the title, author and genre filters to the
+		// ids of the matching books
+		if books, exists := query.Filters["book_id"]; exists {
+			if ids, ok := books.([]int); ok {
+				if !slices.Contains(ids, bookSale.Book.ID) {
+					match = false
 				}
-			}
-			if !genreMatch {
+			} else if id, err := filterInt(books); err != nil {
+				return nil, err
+			} else if bookSale.Book.ID != id {
 				match = false
 			}
 		}
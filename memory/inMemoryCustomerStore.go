@@ -1,17 +1,19 @@
 package memory
 
 import (
-	"errors"
-	"fmt"
+	"strings"
 	"sync"
 
 	"bookstore.com/models"
+	"bookstore.com/repositories"
 )
 
 type InMemoryCustomerStore struct {
 	mu        sync.Mutex
 	Customers map[int]models.Customer
 	nextID    int
+
+	byEmail *hashIndex[models.Customer, string]
 }
 
 var (
@@ -27,6 +29,7 @@ func NewInMemoryCustomerStore() *InMemoryCustomerStore {
 			Customers: make(map[int]models.Customer),
 			nextID:    1,
 		}
+		customerStoreInstance.buildIndexes()
 	})
 	return customerStoreInstance
 }
@@ -37,7 +40,9 @@ func (s *InMemoryCustomerStore) Create(Customer models.Customer) (models.Custome
 	defer s.mu.Unlock()
 
 	Customer.ID = s.nextID
+	Customer.Version = 1
 	s.Customers[s.nextID] = Customer
+	s.byEmail.add(Customer.ID, Customer)
 	s.nextID++
 	return Customer, nil
 }
@@ -47,10 +52,9 @@ func (s *InMemoryCustomerStore) Get(id int) (models.Customer, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	fmt.Println(s.Customers)
 	Customer, exists := s.Customers[id]
 	if !exists {
-		return models.Customer{}, errors.New("Customer not found")
+		return models.Customer{}, repositories.NotFound("customer", id)
 	}
 	return Customer, nil
 }
@@ -60,11 +64,17 @@ func (s *InMemoryCustomerStore) Update(Customer models.Customer) (models.Custome
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.Customers[Customer.ID]
+	stored, exists := s.Customers[Customer.ID]
 	if !exists {
-		return models.Customer{}, errors.New("Customer not found")
+		return models.Customer{}, repositories.NotFound("customer", Customer.ID)
+	}
+	if Customer.Version != 0 && Customer.Version != stored.Version {
+		return models.Customer{}, repositories.ErrVersionConflict
 	}
+	Customer.Version = stored.Version + 1
 	s.Customers[Customer.ID] = Customer
+	s.byEmail.remove(stored.ID, stored)
+	s.byEmail.add(Customer.ID, Customer)
 	return Customer, nil
 }
 
@@ -73,22 +83,41 @@ func (s *InMemoryCustomerStore) Delete(id int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.Customers[id]
+	stored, exists := s.Customers[id]
 	if !exists {
-		return errors.New("Customer not found")
+		return repositories.NotFound("customer", id)
 	}
 	delete(s.Customers, id)
+	s.byEmail.remove(id, stored)
 	return nil
 }
 
-// Search filters customers based on the search criteria
+// Search filters customers by email (case insensitive), found with the email index
 func (s *InMemoryCustomerStore) Search(query models.SearchCriteria) ([]models.Customer, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	var results []models.Customer
+	if email, exists := query.Filters["email"]; exists {
+		email, ok := email.(string)
+		if !ok {
+			return nil, repositories.Invalid("invalid_filter", "invalid email filter")
+		}
+		for id := range s.byEmail.lookup(strings.ToLower(email)) {
+			results = append(results, s.Customers[id])
+		}
+		return results, nil
+	}
 	for _, Customer := range s.Customers {
 		results = append(results, Customer)
 	}
 	return results, nil
 }
+
+// buildIndexes indexes the customers by email, lower-cased
+func (s *InMemoryCustomerStore) buildIndexes() {
+	s.byEmail = newHashIndex(func(customer models.Customer) []string { return []string{strings.ToLower(customer.Email)} })
+	for _, customer := range s.Customers {
+		s.byEmail.add(customer.ID, customer)
+	}
+}
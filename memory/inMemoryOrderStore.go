@@ -1,16 +1,21 @@
 package memory
 
 import (
-	"errors"
 	"sync"
+	"time"
 
 	"bookstore.com/models"
+	"bookstore.com/repositories"
 )
 
 type InMemoryOrderStore struct {
 	mu     sync.Mutex
 	Orders map[int]models.Order
 	nextID int
+
+	byCustomer *hashIndex[models.Order, int]
+	byStatus   *hashIndex[models.Order, string]
+	byCreated  *timeIndex[models.Order]
 }
 
 var (
@@ -26,6 +31,7 @@ func NewInMemoryOrderStore() *InMemoryOrderStore {
 			Orders: make(map[int]models.Order),
 			nextID: 1,
 		}
+		orderStoreInstance.buildIndexes()
 	})
 	return orderStoreInstance
 }
@@ -35,8 +41,11 @@ func (s *InMemoryOrderStore) Create(Order models.Order) (models.Order, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	Order = orderOnly(Order)
 	Order.ID = s.nextID
+	Order.Version = 1
 	s.Orders[s.nextID] = Order
+	s.index(Order)
 
 	s.nextID++
 	NewInMemorySalesReportStore().ordersList = append(NewInMemorySalesReportStore().ordersList, Order)
@@ -51,7 +60,7 @@ func (s *InMemoryOrderStore) Get(id int) (models.Order, error) {
 
 	Order, exists := s.Orders[id]
 	if !exists {
-		return models.Order{}, errors.New("Order not found")
+		return models.Order{}, repositories.NotFound("order", id)
 	}
 	return Order, nil
 }
@@ -61,11 +70,18 @@ func (s *InMemoryOrderStore) Update(Order models.Order) (models.Order, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.Orders[Order.ID]
+	stored, exists := s.Orders[Order.ID]
 	if !exists {
-		return models.Order{}, errors.New("Order not found")
+		return models.Order{}, repositories.NotFound("order", Order.ID)
+	}
+	if Order.Version != 0 && Order.Version != stored.Version {
+		return models.Order{}, repositories.ErrVersionConflict
 	}
+	Order = orderOnly(Order)
+	Order.Version = stored.Version + 1
 	s.Orders[Order.ID] = Order
+	s.unindex(stored)
+	s.index(Order)
 	return Order, nil
 }
 
@@ -74,22 +90,103 @@ func (s *InMemoryOrderStore) Delete(id int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.Orders[id]
+	stored, exists := s.Orders[id]
 	if !exists {
-		return errors.New("Order not found")
+		return repositories.NotFound("order", id)
 	}
 	delete(s.Orders, id)
+	s.unindex(stored)
 	return nil
 }
 
-// Search filters orders based on the search criteria
+// Search filters orders by customer_id, status and the from (included) and to (excluded)
+// bounds of created_at
 func (s *InMemoryOrderStore) Search(query models.SearchCriteria) ([]models.Order, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	var from, to time.Time
+	var err error
+	if value, exists := query.Filters["from"]; exists {
+		if from, err = filterTime(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, exists := query.Filters["to"]; exists {
+		if to, err = filterTime(value); err != nil {
+			return nil, err
+		}
+	}
+
+	orders := s.Orders
+	if candidates, indexed := s.candidates(query.Filters, from, to); indexed {
+		orders = make(map[int]models.Order, len(candidates))
+		for id := range candidates {
+			orders[id] = s.Orders[id]
+		}
+	}
 	var results []models.Order
-	for _, Order := range s.Orders {
+	for _, Order := range orders {
+		if customer, exists := query.Filters["customer_id"]; exists {
+			id, err := filterInt(customer)
+			if err != nil {
+				return nil, err
+			}
+			if Order.Customer.ID != id {
+				continue
+			}
+		}
+		if status, exists := query.Filters["status"]; exists && Order.Status != status {
+			continue
+		}
+		if (!from.IsZero() && Order.CreatedAt.Before(from)) || (!to.IsZero() && !Order.CreatedAt.Before(to)) {
+			continue
+		}
 		results = append(results, Order)
 	}
 	return results, nil
 }
+
+// buildIndexes indexes the orders by customer, by status and by creation date
+func (s *InMemoryOrderStore) buildIndexes() {
+	s.byCustomer = newHashIndex(func(order models.Order) []int { return []int{order.Customer.ID} })
+	s.byStatus = newHashIndex(func(order models.Order) []string { return []string{order.Status} })
+	s.byCreated = newTimeIndex(func(order models.Order) time.Time { return order.CreatedAt })
+	for _, order := range s.Orders {
+		s.index(order)
+	}
+}
+
+func (s *InMemoryOrderStore) index(order models.Order) {
+	s.byCustomer.add(order.ID, order)
+	s.byStatus.add(order.ID, order)
+	s.byCreated.add(order.ID, order)
+}
+
+func (s *InMemoryOrderStore) unindex(order models.Order) {
+	s.byCustomer.remove(order.ID, order)
+	s.byStatus.remove(order.ID, order)
+	s.byCreated.remove(order.ID, order)
+}
+
+// candidates returns the orders the customer_id, status and created_at filters may match,
+// found with the indexes, and whether a filter was indexed
+func (s *InMemoryOrderStore) candidates(filters map[string]interface{}, from, to time.Time) (map[int]bool, bool) {
+	var byCustomer, byStatus, byCreated map[int]bool
+	if customer, exists := filters["customer_id"]; exists {
+		if id, err := filterInt(customer); err == nil {
+			byCustomer = s.byCustomer.lookup(id)
+		}
+	}
+	if status, ok := filters["status"].(string); ok {
+		byStatus = s.byStatus.lookup(status)
+	}
+	if !from.IsZero() || !to.IsZero() {
+		// the period is only collected when it holds fewer orders than the other lookups
+		start, end := s.byCreated.span(from, to)
+		if best, indexed := smallest(byCustomer, byStatus); !indexed || end-start < len(best) {
+			byCreated = s.byCreated.ids(start, end)
+		}
+	}
+	return smallest(byCustomer, byStatus, byCreated)
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemoryPurchaseOrderStore struct {
+	mu             sync.Mutex
+	PurchaseOrders map[int]models.PurchaseOrder
+	nextID         int
+}
+
+var (
+	purchaseOrderStoreInstance *InMemoryPurchaseOrderStore
+	purchaseOrderStoreOnce     sync.Once
+)
+
+// NewInMemoryPurchaseOrderStore returns the singleton instance of InMemoryPurchaseOrderStore
+func NewInMemoryPurchaseOrderStore() *InMemoryPurchaseOrderStore {
+	purchaseOrderStoreOnce.Do(func() {
+		purchaseOrderStoreInstance = &InMemoryPurchaseOrderStore{
+			PurchaseOrders: make(map[int]models.PurchaseOrder),
+			nextID:         1,
+		}
+	})
+	return purchaseOrderStoreInstance
+}
+
+// Create adds a new purchase order to the store
+func (s *InMemoryPurchaseOrderStore) Create(order models.PurchaseOrder) (models.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order = purchaseOrderOnly(order)
+	order.ID = s.nextID
+	order.Version = 1
+	s.PurchaseOrders[s.nextID] = order
+	s.nextID++
+	return order, nil
+}
+
+// Get retrieves a purchase order by ID
+func (s *InMemoryPurchaseOrderStore) Get(id int) (models.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, exists := s.PurchaseOrders[id]
+	if !exists {
+		return models.PurchaseOrder{}, repositories.NotFound("purchase order", id)
+	}
+	return order, nil
+}
+
+// Update modifies an existing purchase order in the store
+func (s *InMemoryPurchaseOrderStore) Update(order models.PurchaseOrder) (models.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.PurchaseOrders[order.ID]
+	if !exists {
+		return models.PurchaseOrder{}, repositories.NotFound("purchase order", order.ID)
+	}
+	if order.Version != 0 && order.Version != stored.Version {
+		return models.PurchaseOrder{}, repositories.ErrVersionConflict
+	}
+	order = purchaseOrderOnly(order)
+	order.Version = stored.Version + 1
+	s.PurchaseOrders[order.ID] = order
+	return order, nil
+}
+
+// Delete removes a purchase order by ID
+func (s *InMemoryPurchaseOrderStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.PurchaseOrders[id]
+	if !exists {
+		return repositories.NotFound("purchase order", id)
+	}
+	delete(s.PurchaseOrders, id)
+	return nil
+}
+
+// Search filters purchase orders by supplier_id and status, oldest first
+func (s *InMemoryPurchaseOrderStore) Search(query models.SearchCriteria) ([]models.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	supplierID := 0
+	if value, exists := query.Filters["supplier_id"]; exists {
+		var err error
+		if supplierID, err = filterInt(value); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]models.PurchaseOrder, 0)
+	for _, order := range s.PurchaseOrders {
+		match := true
+
+		if supplierID != 0 && order.SupplierID != supplierID {
+			match = false
+		}
+
+		if status, exists := query.Filters["status"]; exists {
+			if order.Status != status {
+				match = false
+			}
+		}
+
+		if match {
+			results = append(results, order)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
@@ -1,16 +1,19 @@
 package memory
 
 import (
+	"sort"
 	"sync"
 	"time"
 
 	"bookstore.com/models"
+	"bookstore.com/repositories"
 )
 
 type InMemorySalesReportStore struct {
 	mu           sync.Mutex
-	SalesReports []models.SalesReport
+	SalesReports map[int]models.SalesReport
 	ordersList   []models.Order
+	nextID       int
 }
 
 var (
@@ -21,47 +24,106 @@ var (
 func NewInMemorySalesReportStore() *InMemorySalesReportStore {
 	SalesReportOnce.Do(func() {
 		SalesReportInstance = &InMemorySalesReportStore{
-			SalesReports: make([]models.SalesReport, 0),
+			SalesReports: make(map[int]models.SalesReport),
+			nextID:       1,
 		}
 	})
 	return SalesReportInstance
 }
 
+// Create stores a generated sales report
 func (s *InMemorySalesReportStore) Create(salesReport models.SalesReport) (models.SalesReport, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.SalesReports = append(s.SalesReports, salesReport)
+	// reports loaded from database.json do not carry the next ID
+	if s.nextID == 0 {
+		s.nextID = 1
+		for id := range s.SalesReports {
+			if id >= s.nextID {
+				s.nextID = id + 1
+			}
+		}
+	}
+	salesReport.ID = s.nextID
+	s.SalesReports[s.nextID] = salesReport
+	s.nextID++
+	return salesReport, nil
+}
+
+// Get retrieves a sales report by ID
+func (s *InMemorySalesReportStore) Get(id int) (models.SalesReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	salesReport, exists := s.SalesReports[id]
+	if !exists {
+		return models.SalesReport{}, repositories.NotFound("sales report", id)
+	}
 	return salesReport, nil
 }
 
+// Delete removes a sales report by ID
+func (s *InMemorySalesReportStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.SalesReports[id]
+	if !exists {
+		return repositories.NotFound("sales report", id)
+	}
+	delete(s.SalesReports, id)
+	return nil
+}
+
+// Search filters sales reports by period and by the [from, to) window they cover,
+// results are ordered from the oldest period to the most recent one
 func (s *InMemorySalesReportStore) Search(query models.SearchCriteria) ([]models.SalesReport, error) {
-	var results []models.SalesReport
-	if len(query.Filters) == 0 {
-		for _, SalesReport := range s.SalesReports {
-			results = append(results, SalesReport)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var from, to time.Time
+	if value, exists := query.Filters["from"]; exists {
+		var err error
+		if from, err = filterTime(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, exists := query.Filters["to"]; exists {
+		var err error
+		if to, err = filterTime(value); err != nil {
+			return nil, err
 		}
-		return results, nil
 	}
 
-	for _, SalesReport := range s.SalesReports {
+	results := make([]models.SalesReport, 0)
+	for _, salesReport := range s.SalesReports {
 		match := true
 
-		if from, e1 := query.Filters["from"]; e1 {
-			if to, e2 := query.Filters["to"]; e2 {
-				var err error
-				from, err = time.Parse("2021-02-20T08:45:00Z", from.(string))
-				to, err = time.Parse("2021-02-20T08:45:00Z", to.(string))
-				if err != nil {
-					return results, err
-				}
+		if period, exists := query.Filters["period"]; exists {
+			if salesReport.Period != period {
+				match = false
 			}
 		}
 
+		if !from.IsZero() && salesReport.From.Before(from) {
+			match = false
+		}
+
+		if !to.IsZero() && salesReport.To.After(to) {
+			match = false
+		}
+
 		if match {
-			results = append(results, SalesReport)
+			results = append(results, salesReport)
 		}
 	}
 
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].From.Equal(results[j].From) {
+			return results[i].ID < results[j].ID
+		}
+		return results[i].From.Before(results[j].From)
+	})
 	return results, nil
 }
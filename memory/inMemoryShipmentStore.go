@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemoryShipmentStore struct {
+	mu        sync.Mutex
+	Shipments map[int]models.Shipment
+	nextID    int
+}
+
+var (
+	shipmentStoreInstance *InMemoryShipmentStore
+	shipmentStoreOnce     sync.Once
+)
+
+// NewInMemoryShipmentStore returns the singleton instance of InMemoryShipmentStore
+func NewInMemoryShipmentStore() *InMemoryShipmentStore {
+	shipmentStoreOnce.Do(func() {
+		shipmentStoreInstance = &InMemoryShipmentStore{
+			Shipments: make(map[int]models.Shipment),
+			nextID:    1,
+		}
+	})
+	return shipmentStoreInstance
+}
+
+// Create adds a new shipment to the store
+func (s *InMemoryShipmentStore) Create(shipment models.Shipment) (models.Shipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nextID == 0 {
+		for id := range s.Shipments {
+			s.nextID = max(s.nextID, id)
+		}
+		s.nextID++
+	}
+	shipment.ID = s.nextID
+	shipment.Version = 1
+	s.Shipments[s.nextID] = shipment
+	s.nextID++
+	return shipment, nil
+}
+
+// Get retrieves a shipment by ID
+func (s *InMemoryShipmentStore) Get(id int) (models.Shipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shipment, exists := s.Shipments[id]
+	if !exists {
+		return models.Shipment{}, repositories.NotFound("shipment", id)
+	}
+	return shipment, nil
+}
+
+// Update modifies an existing shipment in the store
+func (s *InMemoryShipmentStore) Update(shipment models.Shipment) (models.Shipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.Shipments[shipment.ID]
+	if !exists {
+		return models.Shipment{}, repositories.NotFound("shipment", shipment.ID)
+	}
+	if shipment.Version != 0 && shipment.Version != stored.Version {
+		return models.Shipment{}, repositories.ErrVersionConflict
+	}
+	shipment.Version = stored.Version + 1
+	s.Shipments[shipment.ID] = shipment
+	return shipment, nil
+}
+
+// Search filters shipments by order_id, status, carrier and tracking_number, oldest first
+func (s *InMemoryShipmentStore) Search(query models.SearchCriteria) ([]models.Shipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orderID := 0
+	if value, exists := query.Filters["order_id"]; exists {
+		var err error
+		if orderID, err = filterInt(value); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]models.Shipment, 0)
+	for _, shipment := range s.Shipments {
+		match := true
+
+		if orderID != 0 && shipment.OrderID != orderID {
+			match = false
+		}
+
+		if status, exists := query.Filters["status"]; exists {
+			if shipment.Status != status {
+				match = false
+			}
+		}
+
+		if carrier, exists := query.Filters["carrier"]; exists {
+			if shipment.Carrier != carrier {
+				match = false
+			}
+		}
+
+		if trackingNumber, exists := query.Filters["tracking_number"]; exists {
+			if shipment.TrackingNumber != trackingNumber {
+				match = false
+			}
+		}
+
+		if match {
+			results = append(results, shipment)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"bookstore.com/models"
+)
+
+type InMemoryStockLevelStore struct {
+	mu          sync.Mutex
+	StockLevels map[string]models.StockLevel
+}
+
+var (
+	stockLevelStoreInstance *InMemoryStockLevelStore
+	stockLevelStoreOnce     sync.Once
+)
+
+// NewInMemoryStockLevelStore returns the singleton instance of InMemoryStockLevelStore
+func NewInMemoryStockLevelStore() *InMemoryStockLevelStore {
+	stockLevelStoreOnce.Do(func() {
+		stockLevelStoreInstance = &InMemoryStockLevelStore{
+			StockLevels: make(map[string]models.StockLevel),
+		}
+	})
+	return stockLevelStoreInstance
+}
+
+func stockLevelKey(warehouseID, bookID int) string {
+	return fmt.Sprintf("%d:%d", warehouseID, bookID)
+}
+
+// Get retrieves the stock of a book in a warehouse, zero when it was never stocked there
+func (s *InMemoryStockLevelStore) Get(warehouseID, bookID int) (models.StockLevel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	level, exists := s.StockLevels[stockLevelKey(warehouseID, bookID)]
+	if !exists {
+		return models.StockLevel{WarehouseID: warehouseID, BookID: bookID}, nil
+	}
+	return level, nil
+}
+
+// Save stores the stock of a book in a warehouse
+func (s *InMemoryStockLevelStore) Save(level models.StockLevel) (models.StockLevel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.StockLevels[stockLevelKey(level.WarehouseID, level.BookID)] = level
+	return level, nil
+}
+
+// Search filters stock levels by warehouse_id and book_id
+func (s *InMemoryStockLevelStore) Search(query models.SearchCriteria) ([]models.StockLevel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	warehouseID, bookID := -1, -1
+	var err error
+	if value, exists := query.Filters["warehouse_id"]; exists {
+		if warehouseID, err = filterInt(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, exists := query.Filters["book_id"]; exists {
+		if bookID, err = filterInt(value); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]models.StockLevel, 0)
+	for _, level := range s.StockLevels {
+		if warehouseID != -1 && level.WarehouseID != warehouseID {
+			continue
+		}
+		if bookID != -1 && level.BookID != bookID {
+			continue
+		}
+		results = append(results, level)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].WarehouseID == results[j].WarehouseID {
+			return results[i].BookID < results[j].BookID
+		}
+		return results[i].WarehouseID < results[j].WarehouseID
+	})
+	return results, nil
+}
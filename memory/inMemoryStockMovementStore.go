@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemoryStockMovementStore struct {
+	mu             sync.Mutex
+	StockMovements map[int]models.StockMovement
+	nextID         int
+}
+
+var (
+	stockMovementStoreInstance *InMemoryStockMovementStore
+	stockMovementStoreOnce     sync.Once
+)
+
+// NewInMemoryStockMovementStore returns the singleton instance of InMemoryStockMovementStore
+func NewInMemoryStockMovementStore() *InMemoryStockMovementStore {
+	stockMovementStoreOnce.Do(func() {
+		stockMovementStoreInstance = &InMemoryStockMovementStore{
+			StockMovements: make(map[int]models.StockMovement),
+			nextID:         1,
+		}
+	})
+	return stockMovementStoreInstance
+}
+
+// Create appends a movement to the ledger
+func (s *InMemoryStockMovementStore) Create(movement models.StockMovement) (models.StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movement.ID = s.nextID
+	s.StockMovements[s.nextID] = movement
+	s.nextID++
+	return movement, nil
+}
+
+// Get retrieves a movement by ID
+func (s *InMemoryStockMovementStore) Get(id int) (models.StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movement, exists := s.StockMovements[id]
+	if !exists {
+		return models.StockMovement{}, repositories.NotFound("stock movement", id)
+	}
+	return movement, nil
+}
+
+// Search filters movements by book_id, type and the from / to dates,
+// results are in ledger order
+func (s *InMemoryStockMovementStore) Search(query models.SearchCriteria) ([]models.StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bookID := 0
+	var from, to time.Time
+	var err error
+	if value, exists := query.Filters["book_id"]; exists {
+		if bookID, err = filterInt(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, exists := query.Filters["from"]; exists {
+		if from, err = filterTime(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, exists := query.Filters["to"]; exists {
+		if to, err = filterTime(value); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]models.StockMovement, 0)
+	for _, movement := range s.StockMovements {
+		match := true
+
+		if bookID != 0 && movement.BookID != bookID {
+			match = false
+		}
+
+		if movementType, exists := query.Filters["type"]; exists {
+			if movement.Type != movementType {
+				match = false
+			}
+		}
+
+		if !from.IsZero() && movement.CreatedAt.Before(from) {
+			match = false
+		}
+
+		if !to.IsZero() && movement.CreatedAt.After(to) {
+			match = false
+		}
+
+		if match {
+			results = append(results, movement)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemorySupplierStore struct {
+	mu        sync.Mutex
+	Suppliers map[int]models.Supplier
+	nextID    int
+}
+
+var (
+	supplierStoreInstance *InMemorySupplierStore
+	supplierStoreOnce     sync.Once
+)
+
+// NewInMemorySupplierStore returns the singleton instance of InMemorySupplierStore
+func NewInMemorySupplierStore() *InMemorySupplierStore {
+	supplierStoreOnce.Do(func() {
+		supplierStoreInstance = &InMemorySupplierStore{
+			Suppliers: make(map[int]models.Supplier),
+			nextID:    1,
+		}
+	})
+	return supplierStoreInstance
+}
+
+// Create adds a new supplier to the store
+func (s *InMemorySupplierStore) Create(supplier models.Supplier) (models.Supplier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	supplier.ID = s.nextID
+	supplier.Version = 1
+	s.Suppliers[s.nextID] = supplier
+	s.nextID++
+	return supplier, nil
+}
+
+// Get retrieves a supplier by ID
+func (s *InMemorySupplierStore) Get(id int) (models.Supplier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	supplier, exists := s.Suppliers[id]
+	if !exists {
+		return models.Supplier{}, repositories.NotFound("supplier", id)
+	}
+	return supplier, nil
+}
+
+// Update modifies an existing supplier in the store
+func (s *InMemorySupplierStore) Update(supplier models.Supplier) (models.Supplier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.Suppliers[supplier.ID]
+	if !exists {
+		return models.Supplier{}, repositories.NotFound("supplier", supplier.ID)
+	}
+	if supplier.Version != 0 && supplier.Version != stored.Version {
+		return models.Supplier{}, repositories.ErrVersionConflict
+	}
+	supplier.Version = stored.Version + 1
+	s.Suppliers[supplier.ID] = supplier
+	return supplier, nil
+}
+
+// Delete removes a supplier by ID
+func (s *InMemorySupplierStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.Suppliers[id]
+	if !exists {
+		return repositories.NotFound("supplier", id)
+	}
+	delete(s.Suppliers, id)
+	return nil
+}
+
+// Search filters suppliers by name and kind
+func (s *InMemorySupplierStore) Search(query models.SearchCriteria) ([]models.Supplier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]models.Supplier, 0)
+	for _, supplier := range s.Suppliers {
+		match := true
+
+		if name, exists := query.Filters["name"]; exists {
+			if value, ok := name.(string); !ok || !strings.Contains(supplier.Name, value) {
+				match = false
+			}
+		}
+
+		if kind, exists := query.Filters["kind"]; exists {
+			if supplier.Kind != kind {
+				match = false
+			}
+		}
+
+		if match {
+			results = append(results, supplier)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type InMemoryWarehouseStore struct {
+	mu         sync.Mutex
+	Warehouses map[int]models.Warehouse
+	nextID     int
+}
+
+var (
+	warehouseStoreInstance *InMemoryWarehouseStore
+	warehouseStoreOnce     sync.Once
+)
+
+// NewInMemoryWarehouseStore returns the singleton instance of InMemoryWarehouseStore
+func NewInMemoryWarehouseStore() *InMemoryWarehouseStore {
+	warehouseStoreOnce.Do(func() {
+		warehouseStoreInstance = &InMemoryWarehouseStore{
+			Warehouses: make(map[int]models.Warehouse),
+			nextID:     1,
+		}
+	})
+	return warehouseStoreInstance
+}
+
+// Create adds a new warehouse to the store
+func (s *InMemoryWarehouseStore) Create(warehouse models.Warehouse) (models.Warehouse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	warehouse.ID = s.nextID
+	warehouse.Version = 1
+	s.Warehouses[s.nextID] = warehouse
+	s.nextID++
+	return warehouse, nil
+}
+
+// Get retrieves a warehouse by ID
+func (s *InMemoryWarehouseStore) Get(id int) (models.Warehouse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	warehouse, exists := s.Warehouses[id]
+	if !exists {
+		return models.Warehouse{}, repositories.NotFound("warehouse", id)
+	}
+	return warehouse, nil
+}
+
+// Update modifies an existing warehouse in the store
+func (s *InMemoryWarehouseStore) Update(warehouse models.Warehouse) (models.Warehouse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.Warehouses[warehouse.ID]
+	if !exists {
+		return models.Warehouse{}, repositories.NotFound("warehouse", warehouse.ID)
+	}
+	if warehouse.Version != 0 && warehouse.Version != stored.Version {
+		return models.Warehouse{}, repositories.ErrVersionConflict
+	}
+	warehouse.Version = stored.Version + 1
+	s.Warehouses[warehouse.ID] = warehouse
+	return warehouse, nil
+}
+
+// Delete removes a warehouse by ID
+func (s *InMemoryWarehouseStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.Warehouses[id]
+	if !exists {
+		return repositories.NotFound("warehouse", id)
+	}
+	delete(s.Warehouses, id)
+	return nil
+}
+
+// Search filters warehouses by name and code
+func (s *InMemoryWarehouseStore) Search(query models.SearchCriteria) ([]models.Warehouse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]models.Warehouse, 0)
+	for _, warehouse := range s.Warehouses {
+		match := true
+
+		if name, exists := query.Filters["name"]; exists {
+			if value, ok := name.(string); !ok || !strings.Contains(warehouse.Name, value) {
+				match = false
+			}
+		}
+
+		if code, exists := query.Filters["code"]; exists {
+			if warehouse.Code != code {
+				match = false
+			}
+		}
+
+		if match {
+			results = append(results, warehouse)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
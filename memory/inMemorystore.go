@@ -14,6 +14,15 @@ type InMemoryStore struct {
 	CustomerStore InMemoryCustomerStore
 	OrderStore    InMemoryOrderStore
 	SalesReport   InMemorySalesReportStore
+	StockLedger   InMemoryStockMovementStore
+	Suppliers     InMemorySupplierStore
+	Purchases     InMemoryPurchaseOrderStore
+	Warehouses    InMemoryWarehouseStore
+	StockLevels   InMemoryStockLevelStore
+	Shipments     InMemoryShipmentStore
+	Accounts      InMemoryAccountStore
+	AuthTokens    InMemoryAuthTokenStore
+	APIKeys       InMemoryAPIKeyStore
 }
 
 var (
@@ -60,6 +69,56 @@ func initializeStores(store *InMemoryStore) {
 		store.OrderStore = *NewInMemoryOrderStore()
 	}
 
+	// Initialize SalesReport store if it is not initialized
+	if store.SalesReport.SalesReports == nil {
+		store.SalesReport = *NewInMemorySalesReportStore()
+	}
+
+	// Initialize StockLedger if it is not initialized
+	if store.StockLedger.StockMovements == nil {
+		store.StockLedger = *NewInMemoryStockMovementStore()
+	}
+
+	// Initialize Suppliers if it is not initialized
+	if store.Suppliers.Suppliers == nil {
+		store.Suppliers = *NewInMemorySupplierStore()
+	}
+
+	// Initialize Purchases if it is not initialized
+	if store.Purchases.PurchaseOrders == nil {
+		store.Purchases = *NewInMemoryPurchaseOrderStore()
+	}
+
+	// Initialize Warehouses if it is not initialized
+	if store.Warehouses.Warehouses == nil {
+		store.Warehouses = *NewInMemoryWarehouseStore()
+	}
+
+	// Initialize StockLevels if it is not initialized
+	if store.StockLevels.StockLevels == nil {
+		store.StockLevels = *NewInMemoryStockLevelStore()
+	}
+
+	// Initialize Shipments if it is not initialized
+	if store.Shipments.Shipments == nil {
+		store.Shipments = *NewInMemoryShipmentStore()
+	}
+
+	// Initialize Accounts if it is not initialized
+	if store.Accounts.Accounts == nil {
+		store.Accounts = *NewInMemoryAccountStore()
+	}
+
+	// Initialize AuthTokens if it is not initialized
+	if store.AuthTokens.AuthTokens == nil {
+		store.AuthTokens = *NewInMemoryAuthTokenStore()
+	}
+
+	// Initialize APIKeys if it is not initialized
+	if store.APIKeys.APIKeys == nil {
+		store.APIKeys = *NewInMemoryAPIKeyStore()
+	}
+
 }
 func LoadData() (*InMemoryStore, error) {
 	data, err := os.ReadFile("database.json")
@@ -76,6 +135,8 @@ func LoadData() (*InMemoryStore, error) {
 	if err != nil {
 		return nil, err
 	}
+	normalizeReferences(store)
+	buildIndexes(store)
 
 	return store, nil
 }
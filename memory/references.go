@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"bookstore.com/models"
+)
+
+// The stores hold the references between the entities as ids only: the author of a book,
+// the customer and the books of an order, the books of a purchase order, the book of a sale.
+// The services hydrate them on read, so a renamed author or a new price is never left behind
+// in a stale copy.
+
+func bookOnly(book models.Book) models.Book {
+	book.Author = models.Author{ID: book.Author.ID}
+	return book
+}
+
+func orderOnly(order models.Order) models.Order {
+	order.Customer = models.Customer{ID: order.Customer.ID}
+	if order.Items != nil {
+		items := make([]models.OrderItem, len(order.Items))
+		for i, item := range order.Items {
+			items[i] = orderItemOnly(item)
+		}
+		order.Items = items
+	}
+	return order
+}
+
+func orderItemOnly(item models.OrderItem) models.OrderItem {
+	item.Book = models.Book{ID: item.Book.ID}
+	return item
+}
+
+func purchaseOrderOnly(order models.PurchaseOrder) models.PurchaseOrder {
+	if order.Lines != nil {
+		lines := make([]models.PurchaseOrderLine, len(order.Lines))
+		for i, line := range order.Lines {
+			line.Book = models.Book{ID: line.Book.ID}
+			lines[i] = line
+		}
+		order.Lines = lines
+	}
+	return order
+}
+
+// bookSaleOnly keeps the title of the book of a sale besides its id, the title names the book
+// in the sales reports once the book is deleted
+func bookSaleOnly(sale models.BookSale) models.BookSale {
+	sale.Book = models.Book{ID: sale.Book.ID, Title: sale.Book.Title}
+	return sale
+}
+
+// normalizeReferences replaces the embedded copies saved by earlier versions with their ids.
+// The order items saved without a snapshot keep the title and the price of their copy.
+func normalizeReferences(store *InMemoryStore) {
+	for id, book := range store.BookStore.Books {
+		store.BookStore.Books[id] = bookOnly(book)
+	}
+	for id, order := range store.OrderStore.Orders {
+		for i, item := range order.Items {
+			if item.Title == "" && item.UnitPrice == 0 {
+				order.Items[i].Title = item.Book.Title
+				order.Items[i].UnitPrice = item.Book.Price
+			}
+		}
+		store.OrderStore.Orders[id] = orderOnly(order)
+	}
+	for id, order := range store.Purchases.PurchaseOrders {
+		store.Purchases.PurchaseOrders[id] = purchaseOrderOnly(order)
+	}
+}
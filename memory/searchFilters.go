@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"strconv"
+	"time"
+
+	"bookstore.com/repositories"
+)
+
+// filterTime accepts either a time.Time or an RFC 3339 string as a search filter value
+func filterTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, repositories.Invalid("invalid_filter", "invalid date filter %q", v)
+		}
+		return t, nil
+	default:
+		return time.Time{}, repositories.Invalid("invalid_filter", "invalid date filter")
+	}
+}
+
+// filterInt accepts an int, a JSON number or a numeric string as a search filter value
+func filterInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, repositories.Invalid("invalid_filter", "invalid numeric filter %q", v)
+		}
+		return n, nil
+	default:
+		return 0, repositories.Invalid("invalid_filter", "invalid numeric filter")
+	}
+}
+
+// filterFloat accepts a JSON number, an int or a numeric string as a search filter value
+func filterFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, repositories.Invalid("invalid_filter", "invalid numeric filter %q", v)
+		}
+		return f, nil
+	default:
+		return 0, repositories.Invalid("invalid_filter", "invalid numeric filter")
+	}
+}
+
+// filterBool accepts a bool or a "true"/"false" string as a search filter value
+func filterBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, repositories.Invalid("invalid_filter", "invalid boolean filter %q", v)
+		}
+		return b, nil
+	default:
+		return false, repositories.Invalid("invalid_filter", "invalid boolean filter")
+	}
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"sort"
+	"time"
+)
+
+// hashIndex maps the keys of the entities of a store, e.g. the author of a book or the genres
+// of a book, to the ids of the entities having them. The stores keep it up to date under their
+// lock on Create, Update and Delete, and their Search looks the filtered keys up to scan only
+// the candidates instead of the whole map.
+type hashIndex[E any, K comparable] struct {
+	keys     func(E) []K
+	postings map[K]map[int]bool
+}
+
+func newHashIndex[E any, K comparable](keys func(E) []K) *hashIndex[E, K] {
+	return &hashIndex[E, K]{keys: keys, postings: make(map[K]map[int]bool)}
+}
+
+// add indexes the keys of an entity
+func (idx *hashIndex[E, K]) add(id int, entity E) {
+	for _, key := range idx.keys(entity) {
+		if idx.postings[key] == nil {
+			idx.postings[key] = make(map[int]bool)
+		}
+		idx.postings[key][id] = true
+	}
+}
+
+// remove removes the keys of the stored version of an entity
+func (idx *hashIndex[E, K]) remove(id int, entity E) {
+	for _, key := range idx.keys(entity) {
+		delete(idx.postings[key], id)
+		if len(idx.postings[key]) == 0 {
+			delete(idx.postings, key)
+		}
+	}
+}
+
+// lookup returns the ids of the entities having any of the keys
+func (idx *hashIndex[E, K]) lookup(keys ...K) map[int]bool {
+	if len(keys) == 1 && idx.postings[keys[0]] != nil {
+		return idx.postings[keys[0]]
+	}
+	ids := make(map[int]bool)
+	for _, key := range keys {
+		for id := range idx.postings[key] {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// lookupFunc returns the ids of the entities having a key matching the function. It goes
+// through the distinct keys, far fewer than the entities for a genre.
+func (idx *hashIndex[E, K]) lookupFunc(match func(K) bool) map[int]bool {
+	var keys []K
+	for key := range idx.postings {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return map[int]bool{}
+	}
+	return idx.lookup(keys...)
+}
+
+// timeIndex keeps the ids of the entities sorted by a date, e.g. the creation of an order, to
+// find the entities of a period with a binary search.
+type timeIndex[E any] struct {
+	key     func(E) time.Time
+	entries []timeEntry
+}
+
+type timeEntry struct {
+	at time.Time
+	id int
+}
+
+func newTimeIndex[E any](key func(E) time.Time) *timeIndex[E] {
+	return &timeIndex[E]{key: key}
+}
+
+// search returns the position of the first entry not before at, or of the entry itself
+func (idx *timeIndex[E]) search(at time.Time, id int) int {
+	return sort.Search(len(idx.entries), func(i int) bool {
+		entry := idx.entries[i]
+		return !entry.at.Before(at) && (!entry.at.Equal(at) || entry.id >= id)
+	})
+}
+
+func (idx *timeIndex[E]) add(id int, entity E) {
+	at := idx.key(entity)
+	i := idx.search(at, id)
+	idx.entries = append(idx.entries, timeEntry{})
+	copy(idx.entries[i+1:], idx.entries[i:])
+	idx.entries[i] = timeEntry{at: at, id: id}
+}
+
+func (idx *timeIndex[E]) remove(id int, entity E) {
+	at := idx.key(entity)
+	if i := idx.search(at, id); i < len(idx.entries) && idx.entries[i].id == id {
+		idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+	}
+}
+
+// span returns the positions of the entries dated from from included to to excluded, a zero
+// bound leaving the period open, to count them before collecting their ids
+func (idx *timeIndex[E]) span(from, to time.Time) (int, int) {
+	start, end := 0, len(idx.entries)
+	if !from.IsZero() {
+		start = idx.search(from, 0)
+	}
+	if !to.IsZero() {
+		end = max(start, idx.search(to, 0))
+	}
+	return start, end
+}
+
+// ids returns the ids of the entries between two positions
+func (idx *timeIndex[E]) ids(start, end int) map[int]bool {
+	ids := make(map[int]bool, end-start)
+	for _, entry := range idx.entries[start:end] {
+		ids[entry.id] = true
+	}
+	return ids
+}
+
+// buildIndexes indexes the entities loaded from the snapshot, the stores created by their
+// constructors index theirs as they are added
+func buildIndexes(store *InMemoryStore) {
+	store.BookStore.buildIndexes()
+	store.OrderStore.buildIndexes()
+	store.CustomerStore.buildIndexes()
+	store.Accounts.buildIndexes()
+}
+
+// smallest returns the smallest of the candidate sets found by the indexes, the ones of the
+// filters the indexes do not serve being nil, and whether any index served a filter
+func smallest(candidates ...map[int]bool) (map[int]bool, bool) {
+	var best map[int]bool
+	found := false
+	for _, ids := range candidates {
+		if ids == nil {
+			continue
+		}
+		if !found || len(ids) < len(best) {
+			best, found = ids, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,402 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"bookstore.com/models"
+)
+
+func newBookStore() *InMemoryBookStore {
+	s := &InMemoryBookStore{Books: make(map[int]models.Book), nextID: 1}
+	s.buildIndexes()
+	return s
+}
+
+func newOrderStore() *InMemoryOrderStore {
+	s := &InMemoryOrderStore{Orders: make(map[int]models.Order), nextID: 1}
+	s.buildIndexes()
+	return s
+}
+
+func newCustomerStore() *InMemoryCustomerStore {
+	s := &InMemoryCustomerStore{Customers: make(map[int]models.Customer), nextID: 1}
+	s.buildIndexes()
+	return s
+}
+
+func newAccountStore() *InMemoryAccountStore {
+	s := &InMemoryAccountStore{Accounts: make(map[int]models.Account), nextID: 1}
+	s.buildIndexes()
+	return s
+}
+
+func filters(pairs ...interface{}) models.SearchCriteria {
+	query := models.SearchCriteria{Filters: make(map[string]interface{})}
+	for i := 0; i < len(pairs); i += 2 {
+		query.Filters[pairs[i].(string)] = pairs[i+1]
+	}
+	return query
+}
+
+func bookIDs(t *testing.T, s *InMemoryBookStore, query models.SearchCriteria) []int {
+	t.Helper()
+	books, err := s.Search(query)
+	if err != nil {
+		t.Fatalf("Search(%v): %v", query.Filters, err)
+	}
+	ids := make([]int, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func orderIDs(t *testing.T, s *InMemoryOrderStore, query models.SearchCriteria) []int {
+	t.Helper()
+	orders, err := s.Search(query)
+	if err != nil {
+		t.Fatalf("Search(%v): %v", query.Filters, err)
+	}
+	ids := make([]int, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func expectIDs(t *testing.T, name string, got []int, want ...int) {
+	t.Helper()
+	if want == nil {
+		want = []int{}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("%s: got ids %v, want %v", name, got, want)
+	}
+}
+
+func TestBookIndexesFollowCreateUpdateDelete(t *testing.T) {
+	s := newBookStore()
+	first, _ := s.Create(models.Book{Title: "Dune", Author: models.Author{ID: 1}, Genres: []string{"Science Fiction"}})
+	second, _ := s.Create(models.Book{Title: "Emma", Author: models.Author{ID: 2}, Genres: []string{"Romance", "Classic"}})
+
+	expectIDs(t, "author 1", bookIDs(t, s, filters("author_id", 1)), first.ID)
+	expectIDs(t, "authors 1 and 2", bookIDs(t, s, filters("author_id", []int{1, 2})), first.ID, second.ID)
+	expectIDs(t, "genre substring", bookIDs(t, s, filters("genre", "Fiction")), first.ID)
+	expectIDs(t, "author and genre", bookIDs(t, s, filters("author_id", 2.0, "genre", "Classic")), second.ID)
+
+	first.Author = models.Author{ID: 2}
+	first.Genres = []string{"Classic"}
+	if _, err := s.Update(first); err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, "old author after update", bookIDs(t, s, filters("author_id", 1)))
+	expectIDs(t, "new author after update", bookIDs(t, s, filters("author_id", 2)), first.ID, second.ID)
+	expectIDs(t, "old genre after update", bookIDs(t, s, filters("genre", "Science")))
+	expectIDs(t, "new genre after update", bookIDs(t, s, filters("genre", "Classic")), first.ID, second.ID)
+
+	if err := s.Delete(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, "author after delete", bookIDs(t, s, filters("author_id", 2)), first.ID)
+	expectIDs(t, "genre after delete", bookIDs(t, s, filters("genre", "Romance")))
+}
+
+func TestBookIndexesBuiltFromLoadedBooks(t *testing.T) {
+	s := &InMemoryBookStore{Books: map[int]models.Book{
+		4: {ID: 4, Author: models.Author{ID: 9}, Genres: []string{"Horror"}},
+		7: {ID: 7, Author: models.Author{ID: 9}, Genres: []string{"Poetry"}},
+	}}
+	s.buildIndexes()
+	expectIDs(t, "author", bookIDs(t, s, filters("author_id", 9)), 4, 7)
+	expectIDs(t, "genre", bookIDs(t, s, filters("genre", "Poe")), 7)
+}
+
+func TestOrderIndexesFollowCreateUpdateDelete(t *testing.T) {
+	s := newOrderStore()
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	first, _ := s.Create(models.Order{Customer: models.Customer{ID: 1}, Status: "pending", CreatedAt: day})
+	second, _ := s.Create(models.Order{Customer: models.Customer{ID: 2}, Status: "pending", CreatedAt: day.Add(24 * time.Hour)})
+	third, _ := s.Create(models.Order{Customer: models.Customer{ID: 1}, Status: "shipped", CreatedAt: day.Add(48 * time.Hour)})
+
+	expectIDs(t, "customer", orderIDs(t, s, filters("customer_id", 1)), first.ID, third.ID)
+	expectIDs(t, "status", orderIDs(t, s, filters("status", "pending")), first.ID, second.ID)
+	expectIDs(t, "period", orderIDs(t, s, filters("from", day.Add(time.Hour), "to", day.Add(48*time.Hour))), second.ID)
+	expectIDs(t, "open period", orderIDs(t, s, filters("from", day.Add(24*time.Hour).Format(time.RFC3339))), second.ID, third.ID)
+	expectIDs(t, "customer, status and period", orderIDs(t, s, filters("customer_id", 1, "status", "shipped", "to", day.Add(72*time.Hour))), third.ID)
+
+	first.Status = "shipped"
+	first.CreatedAt = day.Add(96 * time.Hour)
+	first.Customer = models.Customer{ID: 2}
+	if _, err := s.Update(first); err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, "status after update", orderIDs(t, s, filters("status", "pending")), second.ID)
+	expectIDs(t, "customer after update", orderIDs(t, s, filters("customer_id", 1)), third.ID)
+	expectIDs(t, "period after update", orderIDs(t, s, filters("to", day.Add(24*time.Hour))))
+	expectIDs(t, "later period after update", orderIDs(t, s, filters("from", day.Add(72*time.Hour))), first.ID)
+
+	if err := s.Delete(third.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, "status after delete", orderIDs(t, s, filters("status", "shipped")), first.ID)
+	expectIDs(t, "period after delete", orderIDs(t, s, filters("from", day.Add(36*time.Hour), "to", day.Add(60*time.Hour))))
+}
+
+func TestOrdersCreatedAtTheSameTime(t *testing.T) {
+	s := newOrderStore()
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var ids []int
+	for i := 0; i < 5; i++ {
+		order, _ := s.Create(models.Order{Customer: models.Customer{ID: 1}, CreatedAt: at})
+		ids = append(ids, order.ID)
+	}
+	if err := s.Delete(ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, "same instant", orderIDs(t, s, filters("from", at, "to", at.Add(time.Second))), ids[0], ids[1], ids[3], ids[4])
+}
+
+func TestEmailIndexesFollowCreateUpdateDelete(t *testing.T) {
+	customers := newCustomerStore()
+	jane, _ := customers.Create(models.Customer{Name: "Jane", Email: "Jane@Example.com"})
+	customers.Create(models.Customer{Name: "John", Email: "john@example.com"})
+	found, _ := customers.Search(filters("email", "jane@EXAMPLE.com"))
+	if len(found) != 1 || found[0].ID != jane.ID {
+		t.Errorf("customer by email: got %v", found)
+	}
+	jane.Email = "jane@example.org"
+	customers.Update(jane)
+	if found, _ := customers.Search(filters("email", "jane@example.com")); len(found) != 0 {
+		t.Errorf("customer by old email: got %v", found)
+	}
+	customers.Delete(jane.ID)
+	if found, _ := customers.Search(filters("email", "jane@example.org")); len(found) != 0 {
+		t.Errorf("deleted customer by email: got %v", found)
+	}
+
+	accounts := newAccountStore()
+	account, _ := accounts.Create(models.Account{CustomerID: 3, Email: "Ann@example.com"})
+	if found, _ := accounts.Search(filters("email", "ann@example.com")); len(found) != 1 {
+		t.Errorf("account by email: got %v", found)
+	}
+	account.Email = "ann@example.org"
+	accounts.Update(account)
+	if found, _ := accounts.Search(filters("email", "ann@example.org", "customer_id", 3)); len(found) != 1 {
+		t.Errorf("account by new email: got %v", found)
+	}
+	accounts.Delete(account.ID)
+	if found, _ := accounts.Search(filters("email", "ann@example.org")); len(found) != 0 {
+		t.Errorf("deleted account by email: got %v", found)
+	}
+}
+
+// The benchmarks compare the indexed searches with a scan of the whole store applying the
+// same filter, on catalogs of growing size: the indexed lookups stay flat while the scans
+// grow with the catalog. Run them with go test ./memory -bench . -benchtime 20x
+
+var benchmarkSizes = []int{10_000, 100_000, 1_000_000}
+
+var benchmarkGenres = []string{"Fantasy", "Science Fiction", "Horror", "Romance", "History", "Poetry", "Travel", "Crime"}
+
+var (
+	benchmarkBooks  = map[int]*InMemoryBookStore{}
+	benchmarkOrders = map[int]*InMemoryOrderStore{}
+	benchmarkMu     sync.Mutex
+)
+
+var benchmarkStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// booksOf loads a catalog of n books, 20 per author, once per size
+func booksOf(n int) *InMemoryBookStore {
+	benchmarkMu.Lock()
+	defer benchmarkMu.Unlock()
+	if s, exists := benchmarkBooks[n]; exists {
+		return s
+	}
+	s := &InMemoryBookStore{Books: make(map[int]models.Book, n), nextID: n + 1}
+	for id := 1; id <= n; id++ {
+		s.Books[id] = models.Book{
+			ID:     id,
+			Title:  fmt.Sprintf("Book %d", id),
+			Author: models.Author{ID: id%(n/20) + 1},
+			Genres: []string{benchmarkGenres[id%len(benchmarkGenres)]},
+			Price:  float64(id % 100),
+		}
+	}
+	s.buildIndexes()
+	benchmarkBooks[n] = s
+	return s
+}
+
+// ordersOf loads n orders of n/10 customers, one per minute, 1% of them cancelled
+func ordersOf(n int) *InMemoryOrderStore {
+	benchmarkMu.Lock()
+	defer benchmarkMu.Unlock()
+	if s, exists := benchmarkOrders[n]; exists {
+		return s
+	}
+	s := &InMemoryOrderStore{Orders: make(map[int]models.Order, n), nextID: n + 1}
+	for id := 1; id <= n; id++ {
+		status := "delivered"
+		if id%100 == 0 {
+			status = "cancelled"
+		}
+		s.Orders[id] = models.Order{
+			ID:        id,
+			Customer:  models.Customer{ID: id%(n/10) + 1},
+			Status:    status,
+			CreatedAt: benchmarkStart.Add(time.Duration(id) * time.Minute),
+		}
+	}
+	s.buildIndexes()
+	benchmarkOrders[n] = s
+	return s
+}
+
+// scan returns the entities of a map matching a filter, as a search without index does
+func scan[E any](mu *sync.Mutex, entities map[int]E, match func(E) bool) []E {
+	mu.Lock()
+	defer mu.Unlock()
+	var results []E
+	for _, entity := range entities {
+		if match(entity) {
+			results = append(results, entity)
+		}
+	}
+	return results
+}
+
+func benchmarkSearch(b *testing.B, search func() (int, error)) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		count, err := search()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if count == 0 {
+			b.Fatal("no results")
+		}
+	}
+}
+
+func BenchmarkBooksByAuthor(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		s := booksOf(n)
+		b.Run(fmt.Sprintf("indexed/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				books, err := s.Search(filters("author_id", 42))
+				return len(books), err
+			})
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				return len(scan(&s.mu, s.Books, func(book models.Book) bool { return book.Author.ID == 42 })), nil
+			})
+		})
+	}
+}
+
+func BenchmarkBooksByAuthorAndGenre(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		s := booksOf(n)
+		b.Run(fmt.Sprintf("indexed/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				books, err := s.Search(filters("author_id", 42, "genre", "Fiction"))
+				return len(books), err
+			})
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				return len(scan(&s.mu, s.Books, func(book models.Book) bool {
+					return book.Author.ID == 42 && book.Genres[0] == "Science Fiction"
+				})), nil
+			})
+		})
+	}
+}
+
+func BenchmarkOrdersByCustomer(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		s := ordersOf(n)
+		b.Run(fmt.Sprintf("indexed/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				orders, err := s.Search(filters("customer_id", 7))
+				return len(orders), err
+			})
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				return len(scan(&s.mu, s.Orders, func(order models.Order) bool { return order.Customer.ID == 7 })), nil
+			})
+		})
+	}
+}
+
+func BenchmarkOrdersByStatus(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		s := ordersOf(n)
+		b.Run(fmt.Sprintf("indexed/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				orders, err := s.Search(filters("status", "cancelled", "customer_id", 100))
+				return len(orders), err
+			})
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				return len(scan(&s.mu, s.Orders, func(order models.Order) bool {
+					return order.Status == "cancelled" && order.Customer.ID == 100
+				})), nil
+			})
+		})
+	}
+}
+
+func BenchmarkOrdersCreatedInADay(b *testing.B) {
+	from := benchmarkStart.Add(24 * time.Hour)
+	to := from.Add(24 * time.Hour)
+	for _, n := range benchmarkSizes {
+		s := ordersOf(n)
+		b.Run(fmt.Sprintf("indexed/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				orders, err := s.Search(filters("from", from, "to", to))
+				return len(orders), err
+			})
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				return len(scan(&s.mu, s.Orders, func(order models.Order) bool {
+					return !order.CreatedAt.Before(from) && order.CreatedAt.Before(to)
+				})), nil
+			})
+		})
+	}
+}
+
+func BenchmarkCustomersByEmail(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		s := &InMemoryCustomerStore{Customers: make(map[int]models.Customer, n), nextID: n + 1}
+		for id := 1; id <= n; id++ {
+			s.Customers[id] = models.Customer{ID: id, Email: fmt.Sprintf("customer%d@example.com", id)}
+		}
+		s.buildIndexes()
+		email := fmt.Sprintf("customer%d@example.com", n/2)
+		b.Run(fmt.Sprintf("indexed/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				customers, err := s.Search(filters("email", email))
+				return len(customers), err
+			})
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			benchmarkSearch(b, func() (int, error) {
+				return len(scan(&s.mu, s.Customers, func(customer models.Customer) bool { return customer.Email == email })), nil
+			})
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Kinds of authentication tokens
+const (
+	TokenSession       = "session"
+	TokenPasswordReset = "password_reset"
+)
+
+// Account holds the credentials of a customer
+type Account struct {
+	ID           int       `json:"id"`
+	CustomerID   int       `json:"customer_id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Version      int       `json:"version"`
+}
+
+// AuthToken is a session or password reset token, only the SHA-256 hash of the token is kept
+type AuthToken struct {
+	ID         int       `json:"id"`
+	CustomerID int       `json:"customer_id"`
+	Kind       string    `json:"kind"`
+	TokenHash  string    `json:"token_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UsedAt     time.Time `json:"used_at"`
+	Version    int       `json:"version"`
+}
+
+type Registration struct {
+	Name     string  `json:"name" validate:"required,max=200"`
+	Email    string  `json:"email" validate:"required,email"`
+	Password string  `json:"password"`
+	Address  Address `json:"address"`
+}
+
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Session is returned on login, Token is sent back as "Authorization: Bearer <token>"
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Customer  Customer  `json:"customer"`
+}
+
+type PasswordReset struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
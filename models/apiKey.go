@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// APIKey grants non-interactive access to the routes of its scopes (e.g. books:read,
+// orders:write). Only the SHA-256 hash of the key is stored, Prefix identifies the key.
+type APIKey struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name" validate:"required,max=200"`
+	Prefix      string    `json:"prefix"`
+	KeyHash     string    `json:"key_hash,omitempty"`
+	Scopes      []string  `json:"scopes" validate:"min=1"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	RevokedAt   time.Time `json:"revoked_at"`
+	RotatedFrom int       `json:"rotated_from"`
+	ReplacedBy  int       `json:"replaced_by"`
+	Version     int       `json:"version"`
+}
+
+// IssuedAPIKey is returned when a key is created or rotated, the only time Key is visible
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyRotation configures a rotation, the replaced key keeps working during Overlap
+type APIKeyRotation struct {
+	Overlap string `json:"overlap"`
+}
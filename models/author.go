@@ -2,7 +2,8 @@ package models
 
 type Author struct {
 	ID        int    `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
+	FirstName string `json:"first_name" validate:"max=200"`
+	LastName  string `json:"last_name" validate:"max=200"`
 	Bio       string `json:"bio"`
+	Version   int    `json:"version"`
 }
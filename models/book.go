@@ -3,11 +3,18 @@ package models
 import "time"
 
 type Book struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Author      Author    `json:"author"`
-	Genres      []string  `json:"genres"`
-	PublishedAt time.Time `json:"published_at"`
-	Price       float64   `json:"price"`
-	Stock       int       `json:"stock"`
+	ID               int       `json:"id"`
+	Title            string    `json:"title" validate:"required,max=500"`
+	Author           Author    `json:"author" validate:"ref"`
+	Genres           []string  `json:"genres"`
+	PublishedAt      time.Time `json:"published_at"`
+	Price            float64   `json:"price" validate:"min=0"`
+	Stock            int       `json:"stock" validate:"min=0"`
+	ReorderThreshold int       `json:"reorder_threshold" validate:"min=0"`
+	ReorderTarget    int       `json:"reorder_target" validate:"min=0"`
+	Preorderable     bool      `json:"preorderable"`
+	Backorderable    bool      `json:"backorderable"`
+	Description      string    `json:"description" validate:"max=5000"`
+	ISBN             string    `json:"isbn" validate:"max=20"`
+	Version          int       `json:"version"`
 }
@@ -0,0 +1,51 @@
+package models
+
+// FacetCount is a value of a facet and the number of books having it
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// AuthorFacet is an author and the number of their books
+type AuthorFacet struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// YearFacet is a publication year and the number of books published that year
+type YearFacet struct {
+	Year  int `json:"year"`
+	Count int `json:"count"`
+}
+
+// PriceBucket is a price range and the number of books priced in it, from included and to
+// excluded, the last range has no upper bound
+type PriceBucket struct {
+	From  float64  `json:"from"`
+	To    *float64 `json:"to"`
+	Count int      `json:"count"`
+}
+
+// StockFacet is the number of books in stock and out of stock
+type StockFacet struct {
+	InStock    int `json:"in_stock"`
+	OutOfStock int `json:"out_of_stock"`
+}
+
+// BookFacets are the counts of the books matching a search by refinement, only the requested
+// facets are set
+type BookFacets struct {
+	Genre  []FacetCount  `json:"genre,omitempty"`
+	Price  []PriceBucket `json:"price,omitempty"`
+	Author []AuthorFacet `json:"author,omitempty"`
+	Year   []YearFacet   `json:"year,omitempty"`
+	Stock  *StockFacet   `json:"stock,omitempty"`
+}
+
+// BookPage is a list of books and the facets of the whole search
+type BookPage struct {
+	Books  interface{} `json:"books"`
+	Total  int         `json:"total"`
+	Facets BookFacets  `json:"facets"`
+}
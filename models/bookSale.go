@@ -1,6 +1,11 @@
 package models
 
+import "time"
+
 type BookSale struct {
-	Book     `json:"book"`
-	Quantity int `json:"quantity_sold"`
+	ID       int `json:"id"`
+	Book     `json:"book" validate:"ref"`
+	Quantity int       `json:"quantity_sold" validate:"gt=0"`
+	SoldAt   time.Time `json:"sold_at"`
+	Version  int       `json:"version"`
 }
@@ -6,8 +6,9 @@ import (
 
 type Customer struct {
 	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
+	Name      string    `json:"name" validate:"required,max=200"`
+	Email     string    `json:"email" validate:"required,email"`
 	Address   Address   `json:"address"`
 	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
 }
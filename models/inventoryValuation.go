@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Inventory valuation methods
+const (
+	ValuationFIFO    = "fifo"
+	ValuationAverage = "average"
+)
+
+type BookValuation struct {
+	BookID   int     `json:"book_id"`
+	Title    string  `json:"title"`
+	Quantity int     `json:"quantity"`
+	UnitCost float64 `json:"unit_cost"`
+	Value    float64 `json:"value"`
+}
+
+type InventoryValuation struct {
+	Method        string          `json:"method"`
+	At            time.Time       `json:"at"`
+	TotalQuantity int             `json:"total_quantity"`
+	TotalValue    float64         `json:"total_value"`
+	Books         []BookValuation `json:"books"`
+}
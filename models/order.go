@@ -2,11 +2,24 @@ package models
 
 import "time"
 
+// Order statuses
+const (
+	OrderConfirmed   = "confirmed"
+	OrderPreordered  = "preordered"
+	OrderBackordered = "backordered"
+	OrderShipped     = "shipped"
+	OrderDelivered   = "delivered"
+)
+
 type Order struct {
-	ID         int         `json:"id"`
-	Customer   Customer    `json:"customer"`
-	Items      []OrderItem `json:"items"`
-	TotalPrice float64     `json:"total_price"`
-	CreatedAt  time.Time   `json:"created_at"`
-	Status     string      `json:"status"`
+	ID             int               `json:"id"`
+	Customer       Customer          `json:"customer" validate:"ref"`
+	Items          []OrderItem       `json:"items" validate:"min=1,dive"`
+	TotalPrice     float64           `json:"total_price"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Status         string            `json:"status"`
+	Allocations    []OrderAllocation `json:"allocations"`
+	ExpectedShipAt time.Time         `json:"expected_ship_at"`
+	Shipments      []Shipment        `json:"shipments"`
+	Version        int               `json:"version"`
 }
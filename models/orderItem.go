@@ -1,7 +1,25 @@
 package models
 
+import "time"
+
+// Order item statuses
+const (
+	ItemAllocated   = "allocated"
+	ItemPreordered  = "preordered"
+	ItemBackordered = "backordered"
+)
+
+// OrderItem is a book of an order, Pending is the quantity waiting for the book to be
+// published (preordered) or restocked (backordered). Title and UnitPrice are the snapshot of
+// the book when it was ordered, the book itself is hydrated with its current details.
 type OrderItem struct {
-	ID       int  `json:"id"`
-	Book     Book `json:"book"`
-	Quantity int  `json:"quantity"`
+	ID             int       `json:"id"`
+	Book           Book      `json:"book" validate:"ref"`
+	Quantity       int       `json:"quantity" validate:"gt=0"`
+	Title          string    `json:"title"`
+	UnitPrice      float64   `json:"unit_price"`
+	Pending        int       `json:"pending"`
+	Status         string    `json:"status"`
+	ExpectedShipAt time.Time `json:"expected_ship_at"`
+	Version        int       `json:"version"`
 }
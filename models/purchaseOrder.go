@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Purchase order statuses
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+)
+
+type PurchaseOrderLine struct {
+	ID       int     `json:"id"`
+	Book     Book    `json:"book" validate:"ref"`
+	Quantity int     `json:"quantity" validate:"gt=0"`
+	Received int     `json:"received" validate:"min=0"`
+	UnitCost float64 `json:"unit_cost" validate:"min=0"`
+}
+
+type PurchaseOrder struct {
+	ID         int                 `json:"id"`
+	SupplierID int                 `json:"supplier_id" validate:"gt=0"`
+	Lines      []PurchaseOrderLine `json:"lines" validate:"min=1,dive"`
+	TotalCost  float64             `json:"total_cost"`
+	Status     string              `json:"status"`
+	CreatedAt  time.Time           `json:"created_at"`
+	SentAt     time.Time           `json:"sent_at"`
+	ReceivedAt time.Time           `json:"received_at"`
+	Version    int                 `json:"version"`
+}
+
+// PurchaseOrderReceipt is the quantity of a purchase order line received in one delivery
+type PurchaseOrderReceipt struct {
+	LineID   int `json:"line_id"`
+	Quantity int `json:"quantity"`
+}
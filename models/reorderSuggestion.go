@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ReorderSuggestion is raised for a book whose stock is at or below its ReorderThreshold
+// (a zero threshold disables it). The suggested quantity brings the stock back to the
+// ReorderTarget and covers the sales expected until the delivery.
+type ReorderSuggestion struct {
+	BookID            int       `json:"book_id"`
+	Title             string    `json:"title"`
+	Stock             int       `json:"stock"`
+	ReorderThreshold  int       `json:"reorder_threshold"`
+	ReorderTarget     int       `json:"reorder_target"`
+	DailySales        float64   `json:"daily_sales"`
+	SuggestedQuantity int       `json:"suggested_quantity"`
+	CreatedAt         time.Time `json:"created_at"`
+}
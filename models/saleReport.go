@@ -2,9 +2,30 @@ package models
 
 import "time"
 
+// Report periods supported by the report scheduler
+const (
+	DailyReport   = "daily"
+	WeeklyReport  = "weekly"
+	MonthlyReport = "monthly"
+)
+
 type SalesReport struct {
+	ID              int        `json:"id"`
+	Period          string     `json:"period"`
+	From            time.Time  `json:"from"`
+	To              time.Time  `json:"to"`
 	Timestamp       time.Time  `json:"timestamp"`
 	TotalRevenue    float64    `json:"total_revenue"`
 	TotalOrders     int        `json:"total_orders"`
 	TopSellingBooks []BookSale `json:"top_selling_books"`
 }
+
+// SalesReportComparison compares a report with the report of the previous period
+type SalesReportComparison struct {
+	Current              SalesReport `json:"current"`
+	Previous             SalesReport `json:"previous"`
+	RevenueChange        float64     `json:"revenue_change"`
+	RevenueChangePercent float64     `json:"revenue_change_percent"`
+	OrdersChange         int         `json:"orders_change"`
+	OrdersChangePercent  float64     `json:"orders_change_percent"`
+}
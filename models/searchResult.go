@@ -0,0 +1,17 @@
+package models
+
+// SearchHit is a book matching a catalog search, its relevance score and the matching fields
+// with the matching words highlighted
+type SearchHit struct {
+	Book       Book              `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchResults is a page of the books matching a catalog search, the most relevant first
+type SearchResults struct {
+	Query  string      `json:"query"`
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Hits   []SearchHit `json:"hits"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Shipment statuses, in the order a parcel goes through them
+const (
+	ShipmentLabelCreated   = "label_created"
+	ShipmentInTransit      = "in_transit"
+	ShipmentOutForDelivery = "out_for_delivery"
+	ShipmentDelivered      = "delivered"
+	ShipmentException      = "exception"
+)
+
+// ShipmentEvent is a tracking event reported by the carrier
+type ShipmentEvent struct {
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// Package is a parcel of a shipment
+type Package struct {
+	Weight float64          `json:"weight"`
+	Items  []AllocationItem `json:"items"`
+}
+
+// Shipment is a set of packages of an order sent from one warehouse with a carrier
+type Shipment struct {
+	ID             int              `json:"id"`
+	OrderID        int              `json:"order_id"`
+	WarehouseID    int              `json:"warehouse_id"`
+	Carrier        string           `json:"carrier"`
+	TrackingNumber string           `json:"tracking_number"`
+	LabelURL       string           `json:"label_url"`
+	Packages       []Package        `json:"packages"`
+	Items          []AllocationItem `json:"items"`
+	Status         string           `json:"status"`
+	Events         []ShipmentEvent  `json:"events"`
+	CreatedAt      time.Time        `json:"created_at"`
+	ShippedAt      time.Time        `json:"shipped_at"`
+	DeliveredAt    time.Time        `json:"delivered_at"`
+	Version        int              `json:"version"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Stock movement types recorded in the stock ledger
+const (
+	MovementReceipt     = "receipt"
+	MovementSale        = "sale"
+	MovementReturn      = "return"
+	MovementAdjustment  = "adjustment"
+	MovementDamage      = "damage"
+	MovementTransferOut = "transfer_out"
+	MovementTransferIn  = "transfer_in"
+)
+
+// StockMovement is one entry of the stock ledger. Quantity is positive for every type
+// except adjustments, where its sign gives the direction. Balance is the stock of the
+// book once the movement is applied, WarehouseBalance its stock in the warehouse.
+type StockMovement struct {
+	ID               int       `json:"id"`
+	BookID           int       `json:"book_id"`
+	WarehouseID      int       `json:"warehouse_id"`
+	Type             string    `json:"type"`
+	Quantity         int       `json:"quantity"`
+	UnitCost         float64   `json:"unit_cost"`
+	Balance          int       `json:"balance"`
+	WarehouseBalance int       `json:"warehouse_balance"`
+	Reason           string    `json:"reason"`
+	User             string    `json:"user"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Delta returns the signed change of stock caused by the movement
+func (m StockMovement) Delta() int {
+	switch m.Type {
+	case MovementSale, MovementDamage, MovementTransferOut:
+		return -m.Quantity
+	default:
+		return m.Quantity
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// Suggestion completes a query typed so far with a book title or an author name
+type Suggestion struct {
+	Kind  string  `json:"kind"`
+	ID    int     `json:"id"`
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Supplier kinds
+const (
+	SupplierPublisher   = "publisher"
+	SupplierDistributor = "distributor"
+)
+
+type Supplier struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name" validate:"required,max=200"`
+	Kind      string    `json:"kind" validate:"required,oneof=publisher distributor"`
+	Email     string    `json:"email" validate:"email"`
+	Phone     string    `json:"phone"`
+	Address   Address   `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+}
+
+// SupplierCost is the cost of a book at a supplier, computed from the received purchase orders
+type SupplierCost struct {
+	SupplierID       int     `json:"supplier_id"`
+	BookID           int     `json:"book_id"`
+	Title            string  `json:"title"`
+	QuantityReceived int     `json:"quantity_received"`
+	LastUnitCost     float64 `json:"last_unit_cost"`
+	AverageUnitCost  float64 `json:"average_unit_cost"`
+}
+
+// BookMargin compares the selling price of a book with its average purchase cost
+type BookMargin struct {
+	BookID        int     `json:"book_id"`
+	Title         string  `json:"title"`
+	Price         float64 `json:"price"`
+	UnitCost      float64 `json:"unit_cost"`
+	Margin        float64 `json:"margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Order allocation strategies
+const (
+	AllocateByProximity    = "proximity"
+	AllocateByAvailability = "availability"
+)
+
+type Warehouse struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code" validate:"required,max=20"`
+	Name      string    `json:"name" validate:"required,max=200"`
+	Address   Address   `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+}
+
+// StockLevel is the stock of a book in a warehouse, warehouse 0 holds the stock
+// recorded while no warehouse existed
+type StockLevel struct {
+	WarehouseID int `json:"warehouse_id"`
+	BookID      int `json:"book_id"`
+	Quantity    int `json:"quantity"`
+}
+
+// StockTransfer moves stock of a book between two warehouses
+type StockTransfer struct {
+	BookID          int             `json:"book_id"`
+	FromWarehouseID int             `json:"from_warehouse_id"`
+	ToWarehouseID   int             `json:"to_warehouse_id"`
+	Quantity        int             `json:"quantity"`
+	Reason          string          `json:"reason"`
+	User            string          `json:"user"`
+	Movements       []StockMovement `json:"movements"`
+}
+
+type AllocationItem struct {
+	BookID   int `json:"book_id"`
+	Quantity int `json:"quantity"`
+}
+
+// OrderAllocation is the part of an order shipped from one warehouse
+type OrderAllocation struct {
+	WarehouseID int              `json:"warehouse_id"`
+	Items       []AllocationItem `json:"items"`
+}
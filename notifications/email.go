@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// EmailSink sends notifications by email through an SMTP server
+type EmailSink struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+func NewEmailSink(addr, user, password, from string, to []string) *EmailSink {
+	sink := &EmailSink{Addr: addr, From: from}
+	for _, recipient := range to {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			sink.To = append(sink.To, recipient)
+		}
+	}
+	if user != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		sink.Auth = smtp.PlainAuth("", user, password, host)
+	}
+	return sink
+}
+
+func (s *EmailSink) Notify(notification Notification) error {
+	var body strings.Builder
+	body.WriteString("From: " + s.From + "\r\n")
+	body.WriteString("To: " + strings.Join(s.To, ", ") + "\r\n")
+	body.WriteString("Subject: " + notification.Subject + "\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(notification.Message + "\r\n")
+	if notification.Data != nil {
+		data, err := json.MarshalIndent(notification.Data, "", "  ")
+		if err != nil {
+			return err
+		}
+		body.WriteString("\r\n" + string(data) + "\r\n")
+	}
+	return smtp.SendMail(s.Addr, s.Auth, s.From, s.To, []byte(body.String()))
+}
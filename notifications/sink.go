@@ -0,0 +1,49 @@
+package notifications
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// Notification is a message sent to the operators, Data carries the related entities
+type Notification struct {
+	Subject string      `json:"subject"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Sink delivers notifications
+type Sink interface {
+	Notify(notification Notification) error
+}
+
+// LogSink writes the subject and message of notifications to the standard logger, not their
+// data which may carry secrets such as reset tokens
+type LogSink struct{}
+
+func (LogSink) Notify(notification Notification) error {
+	log.Printf("Notification: %s: %s", notification.Subject, notification.Message)
+	return nil
+}
+
+// NewSinkFromEnv builds the sink selected by NOTIFY_SINK (log, webhook or email).
+// The webhook sink posts to NOTIFY_WEBHOOK_URL, the email sink sends through
+// NOTIFY_SMTP_ADDR (host:port) from NOTIFY_EMAIL_FROM to the comma separated
+// NOTIFY_EMAIL_TO, authenticating with NOTIFY_SMTP_USER and NOTIFY_SMTP_PASSWORD when set.
+func NewSinkFromEnv() Sink {
+	switch os.Getenv("NOTIFY_SINK") {
+	case "webhook":
+		return NewWebhookSink(os.Getenv("NOTIFY_WEBHOOK_URL"))
+	case "email":
+		return NewEmailSink(
+			os.Getenv("NOTIFY_SMTP_ADDR"),
+			os.Getenv("NOTIFY_SMTP_USER"),
+			os.Getenv("NOTIFY_SMTP_PASSWORD"),
+			os.Getenv("NOTIFY_EMAIL_FROM"),
+			strings.Split(os.Getenv("NOTIFY_EMAIL_TO"), ","),
+		)
+	default:
+		return LogSink{}
+	}
+}
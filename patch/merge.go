@@ -0,0 +1,23 @@
+package patch
+
+// MergePatch applies an RFC 7396 JSON merge patch to a decoded JSON document: the members
+// of a patch object replace those of the target, null members are removed and any other
+// patch value replaces the target as a whole
+func MergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = MergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
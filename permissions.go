@@ -0,0 +1,126 @@
+package main
+
+import "bookstore.com/auth"
+
+var (
+	staff     = []string{auth.RoleStaff}
+	customers = []string{auth.RoleCustomer, auth.RoleStaff}
+	reporting = []string{auth.RoleReporting, auth.RoleStaff}
+	admins    = []string{auth.RoleAdmin}
+)
+
+// routePermissions lists who may call each route, admins may call every route and routes
+// missing from the table are reserved to them. Customers only reach their own records, API
+// keys reach the routes whose scope they were issued with.
+var routePermissions = auth.Permissions{
+	{Method: "GET", Path: "/books", Public: true, Scope: "books:read"},
+	{Method: "GET", Path: "/books/:id", Public: true, Scope: "books:read"},
+	{Method: "POST", Path: "/books", Roles: staff, Scope: "books:write"},
+	{Method: "PUT", Path: "/books/:id", Roles: staff, Scope: "books:write"},
+	{Method: "PATCH", Path: "/books/:id", Roles: staff, Scope: "books:write"},
+	{Method: "DELETE", Path: "/books/:id", Roles: admins, Scope: "books:write"},
+	{Method: "GET", Path: "/search", Public: true, Scope: "books:read"},
+	{Method: "GET", Path: "/suggest", Public: true, Scope: "books:read"},
+
+	{Method: "GET", Path: "/authors", Public: true, Scope: "authors:read"},
+	{Method: "GET", Path: "/authors/:id", Public: true, Scope: "authors:read"},
+	{Method: "POST", Path: "/authors", Roles: staff, Scope: "authors:write"},
+	{Method: "PUT", Path: "/authors/:id", Roles: staff, Scope: "authors:write"},
+	{Method: "PATCH", Path: "/authors/:id", Roles: staff, Scope: "authors:write"},
+	{Method: "DELETE", Path: "/authors/:id", Roles: admins, Scope: "authors:write"},
+	{Method: "GET", Path: "/authors/:id/books", Public: true, Scope: "books:read"},
+
+	{Method: "POST", Path: "/customers", Roles: staff, Scope: "customers:write"},
+	{Method: "GET", Path: "/customers/:id", Roles: customers, Scope: "customers:read"},
+	{Method: "GET", Path: "/customers", Roles: staff, Scope: "customers:read"},
+	{Method: "PUT", Path: "/customers/:id", Roles: customers, Scope: "customers:write"},
+	{Method: "PATCH", Path: "/customers/:id", Roles: customers, Scope: "customers:write"},
+	{Method: "DELETE", Path: "/customers/:id", Roles: admins, Scope: "customers:write"},
+	{Method: "GET", Path: "/customers/:id/orders", Roles: customers, Scope: "orders:read"},
+
+	{Method: "POST", Path: "/orders", Roles: customers, Scope: "orders:write"},
+	{Method: "GET", Path: "/orders/:id", Roles: customers, Scope: "orders:read"},
+	{Method: "GET", Path: "/orders", Roles: staff, Scope: "orders:read"},
+	{Method: "PUT", Path: "/orders/:id", Roles: staff, Scope: "orders:write"},
+	{Method: "PATCH", Path: "/orders/:id", Roles: staff, Scope: "orders:write"},
+	{Method: "DELETE", Path: "/orders/:id", Roles: admins, Scope: "orders:write"},
+	{Method: "GET", Path: "/orders/:id/items", Roles: customers, Scope: "orders:read"},
+	{Method: "POST", Path: "/orders/:id/items", Roles: staff, Scope: "orders:write"},
+	{Method: "GET", Path: "/backorders", Roles: staff, Scope: "orders:read"},
+	{Method: "POST", Path: "/backorders/fulfill", Roles: staff, Scope: "orders:write"},
+
+	{Method: "POST", Path: "/orders/:id/shipments", Roles: staff, Scope: "shipments:write"},
+	{Method: "GET", Path: "/orders/:id/shipments", Roles: customers, Scope: "shipments:read"},
+	{Method: "GET", Path: "/shipments/:id", Roles: staff, Scope: "shipments:read"},
+	{Method: "GET", Path: "/shipments", Roles: staff, Scope: "shipments:read"},
+	{Method: "POST", Path: "/shipments/:id/track", Roles: staff, Scope: "shipments:write"},
+
+	{Method: "POST", Path: "/auth/register", Public: true},
+	{Method: "POST", Path: "/auth/login", Public: true},
+	{Method: "POST", Path: "/auth/logout"},
+	{Method: "POST", Path: "/auth/password-reset", Public: true},
+	{Method: "POST", Path: "/auth/password-reset/confirm", Public: true},
+	{Method: "GET", Path: "/account", Roles: []string{auth.RoleCustomer}},
+	{Method: "PUT", Path: "/account", Roles: []string{auth.RoleCustomer}},
+	{Method: "GET", Path: "/account/orders", Roles: []string{auth.RoleCustomer}},
+
+	{Method: "POST", Path: "/reports", Roles: reporting, Scope: "reports:write"},
+	{Method: "GET", Path: "/reports/:id", Roles: reporting, Scope: "reports:read"},
+	{Method: "GET", Path: "/reports/:id/comparison", Roles: reporting, Scope: "reports:read"},
+	{Method: "GET", Path: "/reports", Roles: reporting, Scope: "reports:read"},
+	{Method: "DELETE", Path: "/reports/:id", Roles: admins, Scope: "reports:write"},
+	{Method: "GET", Path: "/salesReport", Roles: reporting, Scope: "reports:read"},
+
+	{Method: "POST", Path: "/bookSales", Roles: staff, Scope: "sales:write"},
+	{Method: "GET", Path: "/bookSales/:id", Roles: reporting, Scope: "sales:read"},
+	{Method: "GET", Path: "/bookSales", Roles: reporting, Scope: "sales:read"},
+	{Method: "DELETE", Path: "/bookSales/:id", Roles: admins, Scope: "sales:write"},
+
+	{Method: "POST", Path: "/inventory/movements", Roles: staff, Scope: "inventory:write"},
+	{Method: "GET", Path: "/inventory/movements/:id", Roles: staff, Scope: "inventory:read"},
+	{Method: "GET", Path: "/inventory/books/:id/movements", Roles: staff, Scope: "inventory:read"},
+	{Method: "GET", Path: "/inventory/books/:id/stock", Roles: staff, Scope: "inventory:read"},
+	{Method: "POST", Path: "/inventory/transfers", Roles: staff, Scope: "inventory:write"},
+	{Method: "GET", Path: "/inventory/valuation", Roles: reporting, Scope: "inventory:read"},
+	{Method: "GET", Path: "/inventory/reorder-suggestions", Roles: staff, Scope: "inventory:read"},
+	{Method: "GET", Path: "/inventory/margins", Roles: reporting, Scope: "inventory:read"},
+
+	{Method: "POST", Path: "/suppliers", Roles: staff, Scope: "suppliers:write"},
+	{Method: "GET", Path: "/suppliers/:id", Roles: staff, Scope: "suppliers:read"},
+	{Method: "GET", Path: "/suppliers/:id/costs", Roles: reporting, Scope: "suppliers:read"},
+	{Method: "GET", Path: "/suppliers", Roles: staff, Scope: "suppliers:read"},
+	{Method: "PUT", Path: "/suppliers/:id", Roles: staff, Scope: "suppliers:write"},
+	{Method: "PATCH", Path: "/suppliers/:id", Roles: staff, Scope: "suppliers:write"},
+	{Method: "DELETE", Path: "/suppliers/:id", Roles: admins, Scope: "suppliers:write"},
+
+	{Method: "POST", Path: "/purchaseOrders", Roles: staff, Scope: "purchase-orders:write"},
+	{Method: "GET", Path: "/purchaseOrders/:id", Roles: staff, Scope: "purchase-orders:read"},
+	{Method: "GET", Path: "/purchaseOrders", Roles: staff, Scope: "purchase-orders:read"},
+	{Method: "PUT", Path: "/purchaseOrders/:id", Roles: staff, Scope: "purchase-orders:write"},
+	{Method: "PATCH", Path: "/purchaseOrders/:id", Roles: staff, Scope: "purchase-orders:write"},
+	{Method: "DELETE", Path: "/purchaseOrders/:id", Roles: admins, Scope: "purchase-orders:write"},
+	{Method: "POST", Path: "/purchaseOrders/:id/send", Roles: staff, Scope: "purchase-orders:write"},
+	{Method: "POST", Path: "/purchaseOrders/:id/receive", Roles: staff, Scope: "purchase-orders:write"},
+
+	{Method: "POST", Path: "/warehouses", Roles: admins, Scope: "warehouses:write"},
+	{Method: "GET", Path: "/warehouses/:id", Roles: staff, Scope: "warehouses:read"},
+	{Method: "GET", Path: "/warehouses/:id/stock", Roles: staff, Scope: "warehouses:read"},
+	{Method: "GET", Path: "/warehouses", Roles: staff, Scope: "warehouses:read"},
+	{Method: "PUT", Path: "/warehouses/:id", Roles: admins, Scope: "warehouses:write"},
+	{Method: "PATCH", Path: "/warehouses/:id", Roles: admins, Scope: "warehouses:write"},
+	{Method: "DELETE", Path: "/warehouses/:id", Roles: admins, Scope: "warehouses:write"},
+
+	{Method: "POST", Path: "/apiKeys", Roles: admins},
+	{Method: "GET", Path: "/apiKeys/:id", Roles: admins},
+	{Method: "GET", Path: "/apiKeys", Roles: admins},
+	{Method: "POST", Path: "/apiKeys/:id/rotate", Roles: admins},
+	{Method: "DELETE", Path: "/apiKeys/:id", Roles: admins},
+}
+
+// fieldPermissions restricts who may change some fields through PUT and PATCH, the other fields may
+// be changed by whoever may call the route
+var fieldPermissions = auth.FieldPermissions{
+	{Resource: "books", Field: "stock", Roles: staff},
+	{Resource: "books", Field: "price", Roles: admins},
+	{Resource: "customers", Field: "email", Roles: staff},
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"time"
+
+	"bookstore.com/ratelimit"
+)
+
+// routeRateLimits sets how many requests each client may make per route group. Order
+// creation, report generation and the credential routes are tighter than the default.
+var routeRateLimits = ratelimit.Policy{
+	Default: ratelimit.Rate{Burst: 120, Period: time.Minute},
+	Routes: []ratelimit.Route{
+		{Method: "POST", Path: "/orders", Group: "orders", Rate: ratelimit.Rate{Burst: 10, Period: time.Minute}},
+		{Method: "POST", Path: "/orders/:id/items", Group: "orders", Rate: ratelimit.Rate{Burst: 10, Period: time.Minute}},
+
+		{Method: "POST", Path: "/reports", Group: "reports", Rate: ratelimit.Rate{Burst: 5, Period: time.Minute}},
+
+		{Method: "POST", Path: "/auth/register", Group: "auth", Rate: ratelimit.Rate{Burst: 10, Period: time.Minute}},
+		{Method: "POST", Path: "/auth/login", Group: "auth", Rate: ratelimit.Rate{Burst: 10, Period: time.Minute}},
+		{Method: "POST", Path: "/auth/password-reset", Group: "auth", Rate: ratelimit.Rate{Burst: 10, Period: time.Minute}},
+		{Method: "POST", Path: "/auth/password-reset/confirm", Group: "auth", Rate: ratelimit.Rate{Burst: 10, Period: time.Minute}},
+	},
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// Rate lets a client make Burst requests at once, the tokens refill evenly over Period
+type Rate struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Result is the state of a bucket after a request took a token from it
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, 0 when allowed
+}
+
+// Limiter keeps the token buckets of the clients, an implementation backed by a shared
+// store lets several instances of the server enforce the same limits
+type Limiter interface {
+	// Take takes a token from the bucket of the key, the bucket is created full
+	Take(key string, rate Rate, now time.Time) (Result, error)
+}
+
+// Route assigns the requests of a route to a group, the routes of a group share the buckets
+// of their clients. Path is the route pattern as registered on the router, ":name" segments
+// match any value.
+type Route struct {
+	Method string
+	Path   string
+	Group  string
+	Rate   Rate
+}
+
+// Policy lists the rate of the route groups, requests of other routes use the default rate
+type Policy struct {
+	Default Rate
+	Routes  []Route
+}
+
+// Lookup returns the group and the rate of a request
+func (p Policy) Lookup(method, path string) (string, Rate) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range p.Routes {
+		if route.Method == method && matchPattern(strings.Split(strings.Trim(route.Path, "/"), "/"), segments) {
+			return route.Group, route.Rate
+		}
+	}
+	return "default", p.Default
+}
+
+// NewLimiterFromEnv builds the limiter selected by RATE_LIMIT_BACKEND (memory or off),
+// nil when rate limiting is off
+func NewLimiterFromEnv() Limiter {
+	switch os.Getenv("RATE_LIMIT_BACKEND") {
+	case "off":
+		return nil
+	default:
+		return NewMemoryLimiter()
+	}
+}
+
+func matchPattern(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i := range pattern {
+		if !strings.HasPrefix(pattern[i], ":") && pattern[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of requests between two removals of the idle buckets
+const sweepEvery = 1024
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// MemoryLimiter keeps the buckets in memory, the limits only hold for a single instance
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket)}
+}
+
+func (l *MemoryLimiter) Take(key string, rate Rate, now time.Time) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	perToken := rate.Period / time.Duration(max(rate.Burst, 1))
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(rate.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.period = rate.Period
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(rate.Burst), b.tokens+float64(elapsed)/float64(perToken))
+		b.updated = now
+	}
+
+	result := Result{Limit: rate.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((float64(rate.Burst) - b.tokens) * float64(perToken))
+	return result, nil
+}
+
+// sweep drops the buckets that refilled since their last request, they are created full again
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(l.buckets, key)
+		}
+	}
+}
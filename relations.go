@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"slices"
+
+	"bookstore.com/integrity"
+	"bookstore.com/memory"
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+// registerRelations declares the references between the entities and what deleting a
+// referenced entity does: books keep their authors, orders their customers and books,
+// purchase orders their suppliers and books, shipments their orders and warehouses. The
+// accounts and tokens of a deleted customer are deleted with it, the sales of a deleted
+// book keep their figures without the reference.
+func registerRelations(db *memory.InMemoryStore) {
+	bookSales := memory.NewInMemoryBookSaleStore()
+
+	integrity.Register(
+		integrity.Relation{Parent: "authors", Child: "books", Field: "author", Policy: integrity.Restrict,
+			Children: referencing(db.BookStore.Search, "author_id", func(book models.Book) (int, []int) {
+				return book.ID, []int{book.Author.ID}
+			})},
+
+		integrity.Relation{Parent: "books", Child: "orders", Field: "items.book", Policy: integrity.Restrict,
+			Children: referencing(db.OrderStore.Search, "", func(order models.Order) (int, []int) {
+				ids := make([]int, len(order.Items))
+				for i, item := range order.Items {
+					ids[i] = item.Book.ID
+				}
+				return order.ID, ids
+			})},
+		integrity.Relation{Parent: "books", Child: "purchaseOrders", Field: "lines.book", Policy: integrity.Restrict,
+			Children: referencing(db.Purchases.Search, "", func(order models.PurchaseOrder) (int, []int) {
+				ids := make([]int, len(order.Lines))
+				for i, line := range order.Lines {
+					ids[i] = line.Book.ID
+				}
+				return order.ID, ids
+			})},
+		integrity.Relation{Parent: "books", Child: "bookSales", Field: "book", Policy: integrity.SetNull,
+			Children: referencing(bookSales.Search, "book_id", func(sale models.BookSale) (int, []int) {
+				return sale.ID, []int{sale.Book.ID}
+			}),
+			Apply: func(id int) error {
+				for {
+					sale, err := bookSales.Get(id)
+					if err != nil {
+						return err
+					}
+					sale.Book.ID = 0
+					if _, err = bookSales.Update(sale); !errors.Is(err, repositories.ErrVersionConflict) {
+						return err
+					}
+				}
+			}},
+
+		integrity.Relation{Parent: "customers", Child: "orders", Field: "customer", Policy: integrity.Restrict,
+			Children: referencing(db.OrderStore.Search, "customer_id", func(order models.Order) (int, []int) {
+				return order.ID, []int{order.Customer.ID}
+			})},
+		integrity.Relation{Parent: "customers", Child: "accounts", Field: "customer_id", Policy: integrity.Cascade,
+			Children: referencing(db.Accounts.Search, "customer_id", func(account models.Account) (int, []int) {
+				return account.ID, []int{account.CustomerID}
+			}),
+			Apply: db.Accounts.Delete},
+		integrity.Relation{Parent: "customers", Child: "authTokens", Field: "customer_id", Policy: integrity.Cascade,
+			Children: referencing(db.AuthTokens.Search, "customer_id", func(token models.AuthToken) (int, []int) {
+				return token.ID, []int{token.CustomerID}
+			}),
+			Apply: db.AuthTokens.Delete},
+
+		integrity.Relation{Parent: "orders", Child: "shipments", Field: "order_id", Policy: integrity.Restrict,
+			Children: referencing(db.Shipments.Search, "order_id", func(shipment models.Shipment) (int, []int) {
+				return shipment.ID, []int{shipment.OrderID}
+			})},
+
+		integrity.Relation{Parent: "suppliers", Child: "purchaseOrders", Field: "supplier_id", Policy: integrity.Restrict,
+			Children: referencing(db.Purchases.Search, "supplier_id", func(order models.PurchaseOrder) (int, []int) {
+				return order.ID, []int{order.SupplierID}
+			})},
+
+		integrity.Relation{Parent: "warehouses", Child: "shipments", Field: "warehouse_id", Policy: integrity.Restrict,
+			Children: referencing(db.Shipments.Search, "", func(shipment models.Shipment) (int, []int) {
+				return shipment.ID, []int{shipment.WarehouseID}
+			})},
+	)
+}
+
+// referencing builds the Children function of a relation from the search of the child store
+// and a function returning the id of a child and the ids it references. The search is filtered
+// on the parent id when the store has a filter for the reference, e.g. author_id for books,
+// which its indexes answer without scanning the store.
+func referencing[T any](search func(models.SearchCriteria) ([]T, error), filter string, references func(T) (int, []int)) func(int) ([]int, error) {
+	return func(parentID int) ([]int, error) {
+		filters := map[string]interface{}{}
+		if filter != "" {
+			filters[filter] = parentID
+		}
+		entities, err := search(models.SearchCriteria{Filters: filters})
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, entity := range entities {
+			if id, referenced := references(entity); slices.Contains(referenced, parentID) {
+				ids = append(ids, id)
+			}
+		}
+		slices.Sort(ids)
+		return ids, nil
+	}
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type AccountStore interface {
+	Create(account models.Account) (models.Account, error)
+	Get(idx int) (models.Account, error)
+	Update(item models.Account) (models.Account, error)
+	Delete(idx int) error
+	Search(query models.SearchCriteria) ([]models.Account, error)
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type APIKeyStore interface {
+	Create(key models.APIKey) (models.APIKey, error)
+	Get(idx int) (models.APIKey, error)
+	Update(item models.APIKey) (models.APIKey, error)
+	Search(query models.SearchCriteria) ([]models.APIKey, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type AuthTokenStore interface {
+	Create(token models.AuthToken) (models.AuthToken, error)
+	Get(idx int) (models.AuthToken, error)
+	Update(item models.AuthToken) (models.AuthToken, error)
+	Delete(idx int) error
+	Search(query models.SearchCriteria) ([]models.AuthToken, error)
+}
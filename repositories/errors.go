@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of the errors returned by the stores and the services, the handlers answer each kind
+// with its own HTTP status
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("invalid input")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Error is a domain error, Kind classifies it and Code identifies it for the clients
+// (e.g. "insufficient_stock"). errors.Is matches an Error with its kind.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NotFound reports a missing entity, e.g. NotFound("book", 12)
+func NotFound(entity string, id int) error {
+	return &Error{Kind: ErrNotFound, Code: "not_found", Message: fmt.Sprintf("%s %d not found", entity, id)}
+}
+
+// Conflict reports a request the current state of an entity does not allow
+func Conflict(code, format string, args ...interface{}) error {
+	return &Error{Kind: ErrConflict, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid reports an input breaking a business rule or referencing a missing entity
+func Invalid(code, format string, args ...interface{}) error {
+	return &Error{Kind: ErrValidation, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unauthorized reports missing or wrong credentials
+func Unauthorized(code, format string, args ...interface{}) error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Forbidden reports a caller not allowed to do what it asked
+func Forbidden(code, format string, args ...interface{}) error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrVersionConflict is returned by Update when the entity changed since the given version was read
+var ErrVersionConflict = Conflict("version_conflict", "version conflict: the entity was modified since it was read")
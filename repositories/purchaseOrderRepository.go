@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type PurchaseOrderStore interface {
+	Create(order models.PurchaseOrder) (models.PurchaseOrder, error)
+	Get(idx int) (models.PurchaseOrder, error)
+	Update(item models.PurchaseOrder) (models.PurchaseOrder, error)
+	Delete(idx int) error
+	Search(query models.SearchCriteria) ([]models.PurchaseOrder, error)
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type SalesReportStore interface {
+	Create(report models.SalesReport) (models.SalesReport, error)
+	Get(idx int) (models.SalesReport, error)
+	Delete(idx int) error
+	Search(query models.SearchCriteria) ([]models.SalesReport, error)
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type ShipmentStore interface {
+	Create(shipment models.Shipment) (models.Shipment, error)
+	Get(idx int) (models.Shipment, error)
+	Update(item models.Shipment) (models.Shipment, error)
+	Search(query models.SearchCriteria) ([]models.Shipment, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+// StockLevelStore keeps the stock of every book per warehouse,
+// Get returns an empty level for a book never stocked in a warehouse
+type StockLevelStore interface {
+	Get(warehouseID, bookID int) (models.StockLevel, error)
+	Save(level models.StockLevel) (models.StockLevel, error)
+	Search(query models.SearchCriteria) ([]models.StockLevel, error)
+}
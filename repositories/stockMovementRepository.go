@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+// StockMovementStore is append only, movements are never updated nor deleted
+type StockMovementStore interface {
+	Create(movement models.StockMovement) (models.StockMovement, error)
+	Get(idx int) (models.StockMovement, error)
+	Search(query models.SearchCriteria) ([]models.StockMovement, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type SupplierStore interface {
+	Create(supplier models.Supplier) (models.Supplier, error)
+	Get(idx int) (models.Supplier, error)
+	Update(item models.Supplier) (models.Supplier, error)
+	Delete(idx int) error
+	Search(query models.SearchCriteria) ([]models.Supplier, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"bookstore.com/models"
+)
+
+type WarehouseStore interface {
+	Create(warehouse models.Warehouse) (models.Warehouse, error)
+	Get(idx int) (models.Warehouse, error)
+	Update(item models.Warehouse) (models.Warehouse, error)
+	Delete(idx int) error
+	Search(query models.SearchCriteria) ([]models.Warehouse, error)
+}
@@ -0,0 +1,135 @@
+// Package search is an in-memory full-text index ranking its documents with BM25. The
+// documents are made of weighted fields, their text is split into terms by the analyzer:
+// lower-cased, folded to ASCII, without stop words and stemmed, so "Strikes" matches
+// "striking" and "Émile" matches "emile". Documents are added, replaced and removed one by one
+// as the entities they index change.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a term of a text and the byte offsets of the word it comes from
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Analyze returns the terms of a text
+func Analyze(text string) []string {
+	tokens := Tokenize(text)
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.Term
+	}
+	return terms
+}
+
+// Tokenize splits a text into the words made of letters and digits and returns their terms.
+// A hyphen between two digits does not split a word, so an ISBN written with hyphens is one
+// term.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	runes := []rune(text)
+	offsets := make([]int, len(runes)+1)
+	offset := 0
+	for i, r := range runes {
+		offsets[i] = offset
+		offset += len(string(r))
+	}
+	offsets[len(runes)] = offset
+
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		var word strings.Builder
+		for i < len(runes) {
+			if isWordRune(runes[i]) {
+				word.WriteString(fold(unicode.ToLower(runes[i])))
+			} else if runes[i] != '-' || !unicode.IsDigit(runes[i-1]) || i+1 == len(runes) || !unicode.IsDigit(runes[i+1]) {
+				break
+			}
+			i++
+		}
+		term := word.String()
+		if stopWords[term] {
+			continue
+		}
+		tokens = append(tokens, Token{Term: stem(term), Start: offsets[start], End: offsets[i]})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// folds maps the accented Latin letters to their ASCII letters
+var folds = map[rune]string{'ß': "ss", 'æ': "ae", 'œ': "oe", 'ð': "d", 'þ': "th"}
+
+func init() {
+	for base, variants := range map[string]string{
+		"a": "àáâãäåāăą", "c": "çćĉċč", "d": "ďđ", "e": "èéêëēĕėęě", "g": "ĝğġģ", "h": "ĥħ",
+		"i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ", "l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏő",
+		"r": "ŕŗř", "s": "śŝşš", "t": "ţťŧ", "u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ", "z": "źżž",
+	} {
+		for _, variant := range variants {
+			folds[variant] = base
+		}
+	}
+}
+
+func fold(r rune) string {
+	if folded, exists := folds[r]; exists {
+		return folded
+	}
+	return string(r)
+}
+
+// stopWords are the English words too common to tell documents apart
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"the": true, "to": true, "with": true,
+}
+
+// stem strips the common English inflections of a word: plurals, -ing, -ed and a final e,
+// so the forms of a word share their term. It is lighter than a full Porter stemmer, the terms
+// are only compared with each other.
+func stem(word string) string {
+	if len(word) <= 3 || !unicode.IsLetter(rune(word[0])) {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+	switch {
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		word = undouble(word[:len(word)-3])
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		word = undouble(word[:len(word)-2])
+	}
+	if strings.HasSuffix(word, "e") && len(word) > 3 {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// undouble drops the doubled final consonant left by a suffix, e.g. running to run
+func undouble(word string) string {
+	n := len(word)
+	if n > 2 && word[n-1] == word[n-2] && !strings.ContainsRune("aeiouls", rune(word[n-1])) {
+		return word[:n-1]
+	}
+	return word
+}
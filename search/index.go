@@ -0,0 +1,195 @@
+package search
+
+import (
+	"html"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BM25 parameters: k1 saturates the frequency of a term, b normalizes by the document length
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// snippetWords is the number of words of a field beyond which its highlight is a snippet
+const snippetWords = 24
+
+// Hit is a document matching a query and its relevance score
+type Hit struct {
+	ID    int
+	Score float64
+}
+
+// Index is an inverted index of documents made of weighted fields. The frequency of a term in
+// a document sums its frequency in each field times the weight of the field (BM25F).
+type Index struct {
+	mu          sync.RWMutex
+	weights     map[string]float64
+	documents   map[int]document
+	postings    map[string]map[int]float64
+	totalLength float64
+}
+
+type document struct {
+	fields map[string]string
+	terms  map[string]float64
+	length float64
+}
+
+// NewIndex creates an index of documents with the given field weights, fields without
+// a weight are not indexed
+func NewIndex(weights map[string]float64) *Index {
+	return &Index{
+		weights:   weights,
+		documents: make(map[int]document),
+		postings:  make(map[string]map[int]float64),
+	}
+}
+
+// Add indexes the fields of a document, replacing the previous version of the document
+func (idx *Index) Add(id int, fields map[string]string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	doc := document{fields: fields, terms: make(map[string]float64)}
+	for field, text := range fields {
+		weight := idx.weights[field]
+		for _, term := range Analyze(text) {
+			doc.terms[term] += weight
+			doc.length += weight
+		}
+	}
+	for term, frequency := range doc.terms {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[int]float64)
+		}
+		idx.postings[term][id] = frequency
+	}
+	idx.documents[id] = doc
+	idx.totalLength += doc.length
+}
+
+// Remove removes a document from the index
+func (idx *Index) Remove(id int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id int) {
+	doc, exists := idx.documents[id]
+	if !exists {
+		return
+	}
+	for term := range doc.terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.documents, id)
+	idx.totalLength -= doc.length
+}
+
+// Len returns the number of documents in the index
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.documents)
+}
+
+// Search returns the documents containing any term of the query, the most relevant first
+func (idx *Index) Search(query string) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.documents) == 0 {
+		return []Hit{}
+	}
+	count := float64(len(idx.documents))
+	averageLength := idx.totalLength / count
+	scores := make(map[int]float64)
+	for _, term := range unique(Analyze(query)) {
+		postings := idx.postings[term]
+		matching := float64(len(postings))
+		idf := math.Log(1 + (count-matching+0.5)/(matching+0.5))
+		for id, frequency := range postings {
+			norm := 1 - b + b*idx.documents[id].length/averageLength
+			scores[id] += idf * frequency * (k1 + 1) / (frequency + k1*norm)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits
+}
+
+// Highlights returns the fields of a document matching the query with the matching words
+// wrapped in <mark>, the long fields cut to a snippet around the first match. The text is
+// HTML-escaped.
+func (idx *Index) Highlights(id int, query string) map[string]string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	terms := unique(Analyze(query))
+	highlights := make(map[string]string)
+	for field, text := range idx.documents[id].fields {
+		tokens := Tokenize(text)
+		first := slices.IndexFunc(tokens, func(token Token) bool {
+			return slices.Contains(terms, token.Term)
+		})
+		if first < 0 {
+			continue
+		}
+		from, to := 0, len(tokens)
+		if len(tokens) > snippetWords {
+			from = max(0, first-snippetWords/4)
+			to = min(len(tokens), from+snippetWords)
+		}
+		start, end := 0, len(text)
+		if from > 0 {
+			start = tokens[from].Start
+		}
+		if to < len(tokens) {
+			end = tokens[to-1].End
+		}
+
+		var snippet strings.Builder
+		if start > 0 {
+			snippet.WriteString("…")
+		}
+		position := start
+		for _, token := range tokens[from:to] {
+			if !slices.Contains(terms, token.Term) {
+				continue
+			}
+			snippet.WriteString(html.EscapeString(text[position:token.Start]))
+			snippet.WriteString("<mark>" + html.EscapeString(text[token.Start:token.End]) + "</mark>")
+			position = token.End
+		}
+		snippet.WriteString(html.EscapeString(text[position:end]))
+		if end < len(text) {
+			snippet.WriteString("…")
+		}
+		highlights[field] = snippet.String()
+	}
+	return highlights
+}
+
+func unique(terms []string) []string {
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
@@ -0,0 +1,362 @@
+package search
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Suggestion is an entry completing a query, the best first
+type Suggestion struct {
+	Kind  string
+	ID    int
+	Text  string
+	Score float64
+}
+
+// Suggester completes the queries typed so far with the entries (e.g. titles and names) whose
+// words start with the query words. The words are kept in a trie walked with the rows of the
+// edit distance to the query word, so a word is found despite typos: one edit in words of 4 to
+// 7 letters, two from 8 letters. The last query word is a prefix, the others whole words.
+//
+// Every node keeps the most popular entries having a word below it, so completing a short
+// prefix does not walk its whole subtree: a prefix alone is completed with the completions
+// most popular entries, a prefix following other words with the entries matching them.
+type Suggester struct {
+	mu      sync.RWMutex
+	root    *trieNode
+	entries map[entryKey]*entry
+}
+
+type entryKey struct {
+	kind string
+	id   int
+}
+
+type entry struct {
+	key   entryKey
+	text  string
+	words []string
+	boost float64
+}
+
+// completions is the number of most popular entries kept per node of the trie
+const completions = 100
+
+type trieNode struct {
+	children map[rune]*trieNode
+	entries  map[*entry]bool
+	// top holds the most popular entries having a word at or below the node, the best first
+	top []*entry
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[rune]*trieNode)}
+}
+
+func NewSuggester() *Suggester {
+	return &Suggester{root: newTrieNode(), entries: make(map[entryKey]*entry)}
+}
+
+// Add adds an entry or replaces its text, keeping its boost
+func (s *Suggester) Add(kind string, id int, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := entryKey{kind: kind, id: id}
+	boost := 0.0
+	if previous, exists := s.entries[key]; exists {
+		boost = previous.boost
+		s.remove(previous)
+	}
+	e := &entry{key: key, text: text, words: suggestWords(text), boost: boost}
+	for _, word := range e.words {
+		node := s.root
+		node.insert(e)
+		for _, r := range word {
+			child, exists := node.children[r]
+			if !exists {
+				child = newTrieNode()
+				node.children[r] = child
+			}
+			node = child
+			node.insert(e)
+		}
+		if node.entries == nil {
+			node.entries = make(map[*entry]bool)
+		}
+		node.entries[e] = true
+	}
+	s.entries[key] = e
+}
+
+// Remove removes an entry
+func (s *Suggester) Remove(kind string, id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, exists := s.entries[entryKey{kind: kind, id: id}]; exists {
+		s.remove(e)
+	}
+}
+
+func (s *Suggester) remove(e *entry) {
+	for _, word := range e.words {
+		node := s.root
+		for _, r := range word {
+			if node = node.children[r]; node == nil {
+				break
+			}
+		}
+		if node != nil {
+			delete(node.entries, e)
+		}
+	}
+	delete(s.entries, e.key)
+	s.rank(e, true)
+}
+
+// SetBoost sets the popularity of an entry, added to its score
+func (s *Suggester) SetBoost(kind string, id int, boost float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, exists := s.entries[entryKey{kind: kind, id: id}]
+	if !exists || e.boost == boost {
+		return
+	}
+	lower := boost < e.boost
+	e.boost = boost
+	s.rank(e, lower)
+}
+
+// rank moves an entry in the most popular entries of the nodes of its words. An entry gaining
+// popularity is only moved up, one removed or losing popularity may leave room for another
+// one, the nodes it was among the most popular of merge again their entries and children.
+func (s *Suggester) rank(e *entry, lower bool) {
+	depths := map[*trieNode]int{s.root: 0}
+	for _, word := range e.words {
+		node := s.root
+		for depth, r := range []rune(word) {
+			if node = node.children[r]; node == nil {
+				break
+			}
+			depths[node] = depth + 1
+		}
+	}
+	nodes := slices.Collect(maps.Keys(depths))
+	// the deepest nodes first, a node merges the most popular entries of its children
+	sort.Slice(nodes, func(i, j int) bool { return depths[nodes[i]] > depths[nodes[j]] })
+	for _, node := range nodes {
+		switch {
+		case !lower:
+			node.insert(e)
+		case slices.Contains(node.top, e):
+			node.merge()
+		}
+	}
+}
+
+// insert adds an entry to the most popular entries of the node, or moves it, if it ranks among
+// them
+func (n *trieNode) insert(e *entry) {
+	if i := slices.Index(n.top, e); i >= 0 {
+		n.top = slices.Delete(n.top, i, i+1)
+	}
+	i := sort.Search(len(n.top), func(i int) bool { return e.before(n.top[i]) })
+	if i >= completions {
+		return
+	}
+	n.top = slices.Insert(n.top, i, e)
+	if len(n.top) > completions {
+		n.top = n.top[:completions]
+	}
+}
+
+// merge computes again the most popular entries of the node from its entries and the most
+// popular entries of its children
+func (n *trieNode) merge() {
+	seen := make(map[*entry]bool)
+	var top []*entry
+	for e := range n.entries {
+		seen[e] = true
+		top = append(top, e)
+	}
+	for _, child := range n.children {
+		for _, e := range child.top {
+			if !seen[e] {
+				seen[e] = true
+				top = append(top, e)
+			}
+		}
+	}
+	sort.Slice(top, func(i, j int) bool { return top[i].before(top[j]) })
+	n.top = top[:min(len(top), completions)]
+}
+
+// before ranks the most popular entries first, then the shortest
+func (e *entry) before(other *entry) bool {
+	if e.boost != other.boost {
+		return e.boost > other.boost
+	}
+	if len(e.text) != len(other.text) {
+		return len(e.text) < len(other.text)
+	}
+	if e.text != other.text {
+		return e.text < other.text
+	}
+	if e.key.kind != other.key.kind {
+		return e.key.kind < other.key.kind
+	}
+	return e.key.id < other.key.id
+}
+
+// Suggest returns the entries matching every word of the query, the closest and most popular
+// first. An entry scores 1 per matched query word minus 0.5 per edit, plus 0.5 when its text
+// starts like the query, plus its boost.
+func (s *Suggester) Suggest(query string, limit int) []Suggestion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	words := suggestWords(query)
+	if len(words) == 0 {
+		return []Suggestion{}
+	}
+	var distances map[*entry]int
+	for i, word := range words {
+		matches := make(map[*entry]int)
+		s.match(word, i == len(words)-1, distances, matches)
+		if distances == nil {
+			distances = matches
+			continue
+		}
+		for e, distance := range distances {
+			if other, exists := matches[e]; exists {
+				distances[e] = distance + other
+			} else {
+				delete(distances, e)
+			}
+		}
+	}
+
+	prefix := strings.Join(words, " ")
+	suggestions := make([]Suggestion, 0, len(distances))
+	for e, distance := range distances {
+		score := float64(len(words)) - 0.5*float64(distance) + e.boost
+		if strings.HasPrefix(strings.Join(e.words, " "), prefix) {
+			score += 0.5
+		}
+		suggestions = append(suggestions, Suggestion{Kind: e.key.kind, ID: e.key.id, Text: e.text, Score: math.Round(score*1000) / 1000})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if len(suggestions[i].Text) != len(suggestions[j].Text) {
+			return len(suggestions[i].Text) < len(suggestions[j].Text)
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// match adds the entries having a word within the edit distance allowed for the query word,
+// or starting within it when prefix is set, with their smallest distance. Swapping two
+// adjacent letters counts as one edit. The words starting with a prefix are not walked: they
+// are the most popular entries of its node, or the candidates matching the previous query
+// words when given.
+func (s *Suggester) match(word string, prefix bool, candidates, matches map[*entry]int) {
+	query := []rune(word)
+	allowed := allowedEdits(len(query))
+	row := make([]int, len(query)+1)
+	for i := range row {
+		row[i] = i
+	}
+	add := func(e *entry, distance int) {
+		if current, exists := matches[e]; !exists || distance < current {
+			matches[e] = distance
+		}
+	}
+	complete := func(node *trieNode, path []rune, distance int) {
+		if candidates == nil {
+			for _, e := range node.top {
+				add(e, distance)
+			}
+			return
+		}
+		start := string(path)
+		for e := range candidates {
+			if slices.ContainsFunc(e.words, func(word string) bool { return strings.HasPrefix(word, start) }) {
+				add(e, distance)
+			}
+		}
+	}
+
+	// best is the distance of the closest prefix of the words below the node
+	var walk func(node *trieNode, path []rune, previous, row []int, last rune, best int)
+	walk = func(node *trieNode, path []rune, previous, row []int, last rune, best int) {
+		distance := row[len(query)]
+		if prefix {
+			distance = min(distance, best)
+		}
+		if distance <= allowed {
+			for e := range node.entries {
+				add(e, distance)
+			}
+			if prefix {
+				complete(node, path, distance)
+			}
+		}
+		for r, child := range node.children {
+			next := make([]int, len(query)+1)
+			next[0] = row[0] + 1
+			smallest := next[0]
+			for i := 1; i <= len(query); i++ {
+				cost := 1
+				if query[i-1] == r {
+					cost = 0
+				}
+				next[i] = min(next[i-1]+1, row[i]+1, row[i-1]+cost)
+				if previous != nil && i > 1 && query[i-1] == last && query[i-2] == r {
+					next[i] = min(next[i], previous[i-2]+1)
+				}
+				smallest = min(smallest, next[i])
+			}
+			// the words below a child no closer to the query are completions of this node
+			if smallest <= allowed {
+				walk(child, append(path, r), row, next, r, distance)
+			}
+		}
+	}
+	walk(s.root, nil, nil, row, 0, row[len(query)])
+}
+
+// allowedEdits is the number of typos tolerated in a word of the given length
+func allowedEdits(length int) int {
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	}
+	return 0
+}
+
+// suggestWords returns the lower-cased and folded words of a text, not stemmed since the
+// words are completed while they are typed
+func suggestWords(text string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return !isWordRune(r) }) {
+		var word strings.Builder
+		for _, r := range field {
+			word.WriteString(fold(unicode.ToLower(r)))
+		}
+		words = append(words, word.String())
+	}
+	return words
+}
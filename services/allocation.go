@@ -0,0 +1,90 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+// allocate splits the requested books between the warehouses. The warehouses are ranked by
+// proximity to the customer address or by the quantity they can ship, a single warehouse
+// fulfilling the whole order is preferred, otherwise the order is split following the ranking.
+func allocate(requested []models.AllocationItem, warehouses []models.Warehouse, available map[int]map[int]int, address models.Address, strategy string) ([]models.OrderAllocation, error) {
+	ranked := make([]models.Warehouse, len(warehouses))
+	copy(ranked, warehouses)
+
+	fulfillable := func(warehouseID int) int {
+		total := 0
+		for _, item := range requested {
+			total += min(item.Quantity, available[warehouseID][item.BookID])
+		}
+		return total
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if strategy == models.AllocateByAvailability {
+			return fulfillable(ranked[i].ID) > fulfillable(ranked[j].ID)
+		}
+		return proximity(ranked[i].Address, address) > proximity(ranked[j].Address, address)
+	})
+
+	for _, warehouse := range ranked {
+		complete := true
+		for _, item := range requested {
+			if available[warehouse.ID][item.BookID] < item.Quantity {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return []models.OrderAllocation{{WarehouseID: warehouse.ID, Items: requested}}, nil
+		}
+	}
+
+	remaining := make(map[int]int)
+	for _, item := range requested {
+		remaining[item.BookID] = item.Quantity
+	}
+	var allocations []models.OrderAllocation
+	for _, warehouse := range ranked {
+		allocation := models.OrderAllocation{WarehouseID: warehouse.ID}
+		for _, item := range requested {
+			quantity := min(remaining[item.BookID], available[warehouse.ID][item.BookID])
+			if quantity > 0 {
+				allocation.Items = append(allocation.Items, models.AllocationItem{BookID: item.BookID, Quantity: quantity})
+				remaining[item.BookID] -= quantity
+			}
+		}
+		if len(allocation.Items) > 0 {
+			allocations = append(allocations, allocation)
+		}
+	}
+
+	for _, item := range requested {
+		if remaining[item.BookID] > 0 {
+			return nil, repositories.Conflict("insufficient_stock", "insufficient stock for book %d: %d missing", item.BookID, remaining[item.BookID])
+		}
+	}
+	return allocations, nil
+}
+
+// proximity scores how close a warehouse is to an address: 4 for the same postal code,
+// 3 for the same city, 2 for the same state, 1 for the same country and 0 otherwise
+func proximity(warehouse, customer models.Address) int {
+	same := func(a, b string) bool {
+		return a != "" && strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+	switch {
+	case same(warehouse.Country, customer.Country) && same(warehouse.PostalCode, customer.PostalCode):
+		return 4
+	case same(warehouse.Country, customer.Country) && same(warehouse.City, customer.City):
+		return 3
+	case same(warehouse.Country, customer.Country) && same(warehouse.State, customer.State):
+		return 2
+	case same(warehouse.Country, customer.Country):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+// ErrInvalidAPIKey is returned for unknown, expired or revoked API keys
+var ErrInvalidAPIKey = repositories.Unauthorized("invalid_api_key", "invalid, expired or revoked API key")
+
+// APIKeyPrefix starts every API key, keys read "bk_<prefix>_<secret>"
+const APIKeyPrefix = "bk_"
+
+// DefaultAPIKeyTTL is the lifetime of the keys issued without an expiry
+const DefaultAPIKeyTTL = 90 * 24 * time.Hour
+
+// DefaultAPIKeyOverlap is how long a rotated key keeps working when no overlap is given
+const DefaultAPIKeyOverlap = 24 * time.Hour
+
+// APIKeyService issues the API keys of the machine clients. Keys are random, only their
+// SHA-256 hash is stored and they are looked up by their prefix.
+type APIKeyService struct {
+	repo   repositories.APIKeyStore
+	scopes []string // scopes a key may be issued with
+
+	mu sync.Mutex
+}
+
+// NewAPIKeyService creates the API key service, keys may only hold the given scopes
+func NewAPIKeyService(repo repositories.APIKeyStore, scopes []string) *APIKeyService {
+	return &APIKeyService{repo: repo, scopes: scopes}
+}
+
+// Issue creates a key with the name, scopes and expiry of the given key, the key is only
+// returned here and expires after DefaultAPIKeyTTL unless an expiry is given
+func (s *APIKeyService) Issue(key models.APIKey) (models.IssuedAPIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.TrimSpace(key.Name) == "" {
+		return models.IssuedAPIKey{}, repositories.Invalid("missing_name", "an API key needs a name")
+	}
+	if len(key.Scopes) == 0 {
+		return models.IssuedAPIKey{}, repositories.Invalid("missing_scopes", "an API key needs at least one scope")
+	}
+	for _, scope := range key.Scopes {
+		if !slices.Contains(s.scopes, scope) {
+			return models.IssuedAPIKey{}, repositories.Invalid("unknown_scope", "unknown scope %q, known scopes: %s", scope, strings.Join(s.scopes, ", "))
+		}
+	}
+	now := time.Now()
+	if key.ExpiresAt.IsZero() {
+		key.ExpiresAt = now.Add(DefaultAPIKeyTTL)
+	}
+	if !key.ExpiresAt.After(now) {
+		return models.IssuedAPIKey{}, repositories.Invalid("invalid_expiry", "expires_at must be in the future")
+	}
+	return s.issue(models.APIKey{
+		Name:      key.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(key.Scopes))),
+		CreatedAt: now,
+		ExpiresAt: key.ExpiresAt,
+	})
+}
+
+func (s *APIKeyService) GetAPIKey(id int) (models.APIKey, error) {
+	key, err := s.repo.Get(id)
+	key.KeyHash = ""
+	return key, err
+}
+
+func (s *APIKeyService) SearchAPIKeys(query models.SearchCriteria) ([]models.APIKey, error) {
+	keys, err := s.repo.Search(query)
+	for i := range keys {
+		keys[i].KeyHash = ""
+	}
+	return keys, err
+}
+
+// Rotate issues a new key with the name, scopes and lifetime of an active key. The old key
+// keeps working for the overlap so the clients can switch, then expires.
+func (s *APIKeyService) Rotate(id int, overlap time.Duration) (models.IssuedAPIKey, error) {
+	if overlap < 0 {
+		return models.IssuedAPIKey{}, repositories.Invalid("invalid_overlap", "overlap must not be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.repo.Get(id)
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	now := time.Now()
+	if !old.RevokedAt.IsZero() || !now.Before(old.ExpiresAt) {
+		return models.IssuedAPIKey{}, repositories.Conflict("api_key_inactive", "only an active API key can be rotated")
+	}
+	if old.ReplacedBy != 0 {
+		return models.IssuedAPIKey{}, repositories.Conflict("api_key_rotated", "API key %d was already rotated to %d", old.ID, old.ReplacedBy)
+	}
+
+	issued, err := s.issue(models.APIKey{
+		Name:        old.Name,
+		Scopes:      old.Scopes,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(old.ExpiresAt.Sub(old.CreatedAt)),
+		RotatedFrom: old.ID,
+	})
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	old.ReplacedBy = issued.ID
+	if end := now.Add(overlap); end.Before(old.ExpiresAt) {
+		old.ExpiresAt = end
+	}
+	if _, err := s.repo.Update(old); err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	return issued, nil
+}
+
+// Revoke disables a key immediately
+func (s *APIKeyService) Revoke(id int) (models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := s.repo.Get(id)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if key.RevokedAt.IsZero() {
+		key.RevokedAt = time.Now()
+		if key, err = s.repo.Update(key); err != nil {
+			return models.APIKey{}, err
+		}
+	}
+	key.KeyHash = ""
+	return key, nil
+}
+
+// Authenticate returns the active key matching a presented key and records its use
+func (s *APIKeyService) Authenticate(presented string) (models.APIKey, error) {
+	prefix, ok := apiKeyPrefix(presented)
+	if !ok {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.repo.Search(models.SearchCriteria{Filters: map[string]interface{}{"prefix": prefix}})
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	now := time.Now()
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashToken(presented))) != 1 {
+			continue
+		}
+		if !key.RevokedAt.IsZero() || !now.Before(key.ExpiresAt) {
+			return models.APIKey{}, ErrInvalidAPIKey
+		}
+		key.LastUsedAt = now
+		if key, err = s.repo.Update(key); err != nil {
+			return models.APIKey{}, err
+		}
+		key.KeyHash = ""
+		return key, nil
+	}
+	return models.APIKey{}, ErrInvalidAPIKey
+}
+
+// issue generates the key of a new record and stores its hash
+func (s *APIKeyService) issue(key models.APIKey) (models.IssuedAPIKey, error) {
+	prefix := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	key.Prefix = hex.EncodeToString(prefix)
+	presented := APIKeyPrefix + key.Prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	key.KeyHash = hashToken(presented)
+
+	created, err := s.repo.Create(key)
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	created.KeyHash = ""
+	return models.IssuedAPIKey{APIKey: created, Key: presented}, nil
+}
+
+// apiKeyPrefix returns the prefix of a key formatted as "bk_<prefix>_<secret>"
+func apiKeyPrefix(presented string) (string, bool) {
+	rest, found := strings.CutPrefix(presented, APIKeyPrefix)
+	if !found {
+		return "", false
+	}
+	prefix, secret, found := strings.Cut(rest, "_")
+	return prefix, found && prefix != "" && secret != ""
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/notifications"
+	"bookstore.com/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials is returned for an unknown email or a wrong password alike
+var ErrInvalidCredentials = repositories.Unauthorized("invalid_credentials", "invalid email or password")
+
+// ErrInvalidToken is returned for unknown, expired or already used tokens
+var ErrInvalidToken = repositories.Unauthorized("invalid_token", "invalid or expired token")
+
+// ErrEmailTaken is returned when registering an email that already has an account
+var ErrEmailTaken = repositories.Conflict("email_taken", "email already registered")
+
+// ErrForbidden is returned when a customer reaches the records of another customer
+var ErrForbidden = repositories.Forbidden("forbidden", "not allowed to access the records of another customer")
+
+// dummyPasswordHash is a bcrypt hash compared against when the email is unknown
+var dummyPasswordHash = []byte("$2a$10$7EqJtq98hPqEX7fNZaFWoOhi5BWX4Z3xVaJfNAD9VGxGSbTaKBXRe")
+
+// AuthConfig configures the lifetime of the tokens
+type AuthConfig struct {
+	SessionTTL       time.Duration
+	PasswordResetTTL time.Duration
+	MinPasswordLen   int
+}
+
+var DefaultAuthConfig = AuthConfig{
+	SessionTTL:       24 * time.Hour,
+	PasswordResetTTL: time.Hour,
+	MinPasswordLen:   8,
+}
+
+// AuthService registers customer accounts and issues their session and password reset tokens.
+// Passwords are hashed with bcrypt, tokens are random and only their SHA-256 hash is stored.
+type AuthService struct {
+	accountRepo  repositories.AccountStore
+	tokenRepo    repositories.AuthTokenStore
+	customerRepo repositories.CustomerStore
+	sink         notifications.Sink
+	config       AuthConfig
+
+	mu sync.Mutex
+}
+
+// NewAuthService creates the auth service, password reset tokens are delivered through the sink
+func NewAuthService(accountRepo repositories.AccountStore, tokenRepo repositories.AuthTokenStore, customerRepo repositories.CustomerStore, sink notifications.Sink, config AuthConfig) *AuthService {
+	return &AuthService{
+		accountRepo:  accountRepo,
+		tokenRepo:    tokenRepo,
+		customerRepo: customerRepo,
+		sink:         sink,
+		config:       config,
+	}
+}
+
+// Register creates a customer and its account, an email can only be registered once
+func (s *AuthService) Register(registration models.Registration) (models.Customer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email, err := normalizeEmail(registration.Email)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	if len(registration.Password) < s.config.MinPasswordLen {
+		return models.Customer{}, repositories.Invalid("weak_password", "password must be at least %d characters", s.config.MinPasswordLen)
+	}
+	accounts, err := s.accountRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"email": email}})
+	if err != nil {
+		return models.Customer{}, err
+	}
+	if len(accounts) > 0 {
+		return models.Customer{}, ErrEmailTaken
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(registration.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.Customer{}, err
+	}
+
+	now := time.Now()
+	customer, err := s.customerRepo.Create(models.Customer{
+		Name:      registration.Name,
+		Email:     email,
+		Address:   registration.Address,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return models.Customer{}, err
+	}
+	if _, err := s.accountRepo.Create(models.Account{
+		CustomerID:   customer.ID,
+		Email:        email,
+		PasswordHash: string(hash),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}); err != nil {
+		s.customerRepo.Delete(customer.ID)
+		return models.Customer{}, err
+	}
+	return customer, nil
+}
+
+// Login checks the credentials of a customer and issues a session token
+func (s *AuthService) Login(credentials models.Credentials) (models.Session, error) {
+	account, err := s.account(credentials.Email)
+	if err != nil {
+		// compare anyway so unknown emails take as long as wrong passwords
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(credentials.Password))
+		return models.Session{}, ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(credentials.Password)) != nil {
+		return models.Session{}, ErrInvalidCredentials
+	}
+	customer, err := s.customerRepo.Get(account.CustomerID)
+	if err != nil {
+		return models.Session{}, ErrInvalidCredentials
+	}
+
+	token, stored, err := s.issue(customer.ID, models.TokenSession, s.config.SessionTTL)
+	if err != nil {
+		return models.Session{}, err
+	}
+	return models.Session{Token: token, ExpiresAt: stored.ExpiresAt, Customer: customer}, nil
+}
+
+// Authenticate returns the customer of a valid session token
+func (s *AuthService) Authenticate(token string) (models.Customer, error) {
+	stored, err := s.find(token, models.TokenSession)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	customer, err := s.customerRepo.Get(stored.CustomerID)
+	if err != nil {
+		return models.Customer{}, ErrInvalidToken
+	}
+	return customer, nil
+}
+
+// Logout revokes a session token
+func (s *AuthService) Logout(token string) error {
+	stored, err := s.find(token, models.TokenSession)
+	if err != nil {
+		return err
+	}
+	return s.tokenRepo.Delete(stored.ID)
+}
+
+// RequestPasswordReset sends a one-time reset token to the owner of the email. Nothing
+// happens for unknown emails so the answer does not reveal which emails are registered.
+// The token is only in the data of the notification, which the log sink does not write.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	account, err := s.account(email)
+	if err != nil {
+		return nil
+	}
+	token, stored, err := s.issue(account.CustomerID, models.TokenPasswordReset, s.config.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.sink.Notify(notifications.Notification{
+		Subject: "Password reset",
+		Message: fmt.Sprintf("A password reset was requested for %s, the token expires at %s", account.Email, stored.ExpiresAt.Format(time.RFC3339)),
+		Data:    map[string]interface{}{"email": account.Email, "token": token, "expires_at": stored.ExpiresAt},
+	})
+}
+
+// ResetPassword sets a new password with a reset token, the token can only be used once
+// and every session of the customer is revoked
+func (s *AuthService) ResetPassword(reset models.PasswordReset) error {
+	if len(reset.Password) < s.config.MinPasswordLen {
+		return repositories.Invalid("weak_password", "password must be at least %d characters", s.config.MinPasswordLen)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.find(reset.Token, models.TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	accounts, err := s.accountRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"customer_id": stored.CustomerID}})
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return ErrInvalidToken
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(reset.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	stored.UsedAt = time.Now()
+	if _, err := s.tokenRepo.Update(stored); err != nil {
+		return err
+	}
+	account := accounts[0]
+	account.PasswordHash = string(hash)
+	account.UpdatedAt = stored.UsedAt
+	if _, err := s.accountRepo.Update(account); err != nil {
+		return err
+	}
+
+	sessions, err := s.tokenRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{
+		"customer_id": stored.CustomerID,
+		"kind":        models.TokenSession,
+	}})
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		s.tokenRepo.Delete(session.ID)
+	}
+	return nil
+}
+
+func (s *AuthService) account(email string) (models.Account, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return models.Account{}, err
+	}
+	accounts, err := s.accountRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"email": email}})
+	if err != nil {
+		return models.Account{}, err
+	}
+	if len(accounts) == 0 {
+		return models.Account{}, repositories.Invalid("unknown_account", "no account is registered with %s", email)
+	}
+	return accounts[0], nil
+}
+
+// issue creates a random token and stores its hash
+func (s *AuthService) issue(customerID int, kind string, ttl time.Duration) (string, models.AuthToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", models.AuthToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	now := time.Now()
+	stored, err := s.tokenRepo.Create(models.AuthToken{
+		CustomerID: customerID,
+		Kind:       kind,
+		TokenHash:  hashToken(token),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	})
+	return token, stored, err
+}
+
+// find returns the stored token of the given kind, unless it expired or was used
+func (s *AuthService) find(token, kind string) (models.AuthToken, error) {
+	if token == "" {
+		return models.AuthToken{}, ErrInvalidToken
+	}
+	tokens, err := s.tokenRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{
+		"token_hash": hashToken(token),
+		"kind":       kind,
+	}})
+	if err != nil {
+		return models.AuthToken{}, err
+	}
+	if len(tokens) == 0 || time.Now().After(tokens[0].ExpiresAt) || !tokens[0].UsedAt.IsZero() {
+		return models.AuthToken{}, ErrInvalidToken
+	}
+	return tokens[0], nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil {
+		return "", repositories.Invalid("invalid_email", "invalid email address")
+	}
+	return email, nil
+}
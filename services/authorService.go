@@ -1,20 +1,47 @@
 package services
 
 import (
+	"sync"
+
+	"bookstore.com/integrity"
 	"bookstore.com/models"
 	"bookstore.com/repositories"
 )
 
 type AuthorService struct {
 	authorRepo repositories.AuthorStore
+
+	mu      sync.Mutex
+	changed []func(authorID int)
 }
 
 func NewAuthorService(repo repositories.AuthorStore) *AuthorService {
 	return &AuthorService{authorRepo: repo}
 }
 
+// OnChange registers a function called after an author was created, updated or deleted
+func (s *AuthorService) OnChange(fn func(authorID int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changed = append(s.changed, fn)
+}
+
+func (s *AuthorService) notify(authorID int) {
+	s.mu.Lock()
+	changed := s.changed
+	s.mu.Unlock()
+	for _, fn := range changed {
+		fn(authorID)
+	}
+}
+
 func (s *AuthorService) CreateAuthor(author models.Author) (models.Author, error) {
-	return s.authorRepo.Create(author)
+	createdAuthor, err := s.authorRepo.Create(author)
+	if err != nil {
+		return models.Author{}, err
+	}
+	s.notify(createdAuthor.ID)
+	return createdAuthor, nil
 }
 
 func (s *AuthorService) GetAuthor(id int) (models.Author, error) {
@@ -22,11 +49,23 @@ func (s *AuthorService) GetAuthor(id int) (models.Author, error) {
 }
 
 func (s *AuthorService) UpdateAuthor(author models.Author) (models.Author, error) {
-	return s.authorRepo.Update(author)
+	updatedAuthor, err := s.authorRepo.Update(author)
+	if err != nil {
+		return models.Author{}, err
+	}
+	s.notify(updatedAuthor.ID)
+	return updatedAuthor, nil
 }
 
 func (s *AuthorService) DeleteAuthor(id int) error {
-	return s.authorRepo.Delete(id)
+	err := integrity.Delete("authors", id, func() error {
+		return s.authorRepo.Delete(id)
+	})
+	if err != nil {
+		return err
+	}
+	s.notify(id)
+	return nil
 }
 
 func (s *AuthorService) SearchAuthors(query models.SearchCriteria) ([]models.Author, error) {
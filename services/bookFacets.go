@@ -0,0 +1,152 @@
+package services
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"bookstore.com/models"
+)
+
+// BookFacetNames are the facets of a book search
+var BookFacetNames = []string{"genre", "price", "author", "year", "stock"}
+
+// PriceBuckets are the lower bounds of the price ranges of the price facet
+var PriceBuckets = []float64{0, 10, 20, 50, 100}
+
+// facetFilters are the filters refining the books by each facet
+var facetFilters = map[string][]string{
+	"genre":  {"genre"},
+	"price":  {"price", "min_price", "max_price"},
+	"author": {"author", "author_id"},
+	"year":   {"year"},
+	"stock":  {"in_stock"},
+}
+
+// BookFacets counts the books matching a search by genre, price range, author, publication
+// year and stock, for the facets listed in names (see BookFacetNames). Each facet is counted without the filters of its
+// own refinement, so the other values of a refined facet keep their counts and the sidebar
+// can offer them: with {"genre": "Fantasy"} the genre facet counts every genre of the books
+// matching the other filters, the other facets count the Fantasy books.
+func (s *BookService) BookFacets(query models.SearchCriteria, names []string) (models.BookFacets, error) {
+	var facets models.BookFacets
+	for _, name := range names {
+		filters := maps.Clone(query.Filters)
+		for _, filter := range facetFilters[name] {
+			delete(filters, filter)
+		}
+		books, err := s.SearchBooks(models.SearchCriteria{Filters: filters})
+		if err != nil {
+			return models.BookFacets{}, err
+		}
+		switch name {
+		case "genre":
+			facets.Genre = genreFacet(books)
+		case "price":
+			facets.Price = priceFacet(books)
+		case "author":
+			facets.Author = authorFacet(books)
+		case "year":
+			facets.Year = yearFacet(books)
+		case "stock":
+			facets.Stock = stockFacet(books)
+		}
+	}
+	return facets, nil
+}
+
+// genreFacet counts the books per genre, the most common first
+func genreFacet(books []models.Book) []models.FacetCount {
+	counts := make(map[string]int)
+	for _, book := range books {
+		for _, genre := range slices.Compact(slices.Sorted(slices.Values(book.Genres))) {
+			counts[genre]++
+		}
+	}
+	genres := make([]models.FacetCount, 0, len(counts))
+	for genre, count := range counts {
+		genres = append(genres, models.FacetCount{Value: genre, Count: count})
+	}
+	sort.Slice(genres, func(i, j int) bool {
+		if genres[i].Count != genres[j].Count {
+			return genres[i].Count > genres[j].Count
+		}
+		return genres[i].Value < genres[j].Value
+	})
+	return genres
+}
+
+// priceFacet counts the books in each price range, the empty ranges included
+func priceFacet(books []models.Book) []models.PriceBucket {
+	buckets := make([]models.PriceBucket, len(PriceBuckets))
+	for i, from := range PriceBuckets {
+		buckets[i].From = from
+		if i+1 < len(PriceBuckets) {
+			to := PriceBuckets[i+1]
+			buckets[i].To = &to
+		}
+	}
+	for _, book := range books {
+		for i := len(buckets) - 1; i >= 0; i-- {
+			if book.Price >= buckets[i].From {
+				buckets[i].Count++
+				break
+			}
+		}
+	}
+	return buckets
+}
+
+// authorFacet counts the books per author, the most prolific first
+func authorFacet(books []models.Book) []models.AuthorFacet {
+	counts := make(map[int]*models.AuthorFacet)
+	for _, book := range books {
+		if counts[book.Author.ID] == nil {
+			counts[book.Author.ID] = &models.AuthorFacet{ID: book.Author.ID, Name: authorName(book.Author)}
+		}
+		counts[book.Author.ID].Count++
+	}
+	authors := make([]models.AuthorFacet, 0, len(counts))
+	for _, author := range counts {
+		authors = append(authors, *author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Count != authors[j].Count {
+			return authors[i].Count > authors[j].Count
+		}
+		return authors[i].ID < authors[j].ID
+	})
+	return authors
+}
+
+// yearFacet counts the books per publication year, the latest first, leaving out the books
+// without a publication date
+func yearFacet(books []models.Book) []models.YearFacet {
+	counts := make(map[int]int)
+	for _, book := range books {
+		if !book.PublishedAt.IsZero() {
+			counts[book.PublishedAt.Year()]++
+		}
+	}
+	years := make([]models.YearFacet, 0, len(counts))
+	for year, count := range counts {
+		years = append(years, models.YearFacet{Year: year, Count: count})
+	}
+	sort.Slice(years, func(i, j int) bool {
+		return years[i].Year > years[j].Year
+	})
+	return years
+}
+
+// stockFacet counts the books in stock and out of stock
+func stockFacet(books []models.Book) *models.StockFacet {
+	stock := &models.StockFacet{}
+	for _, book := range books {
+		if book.Stock > 0 {
+			stock.InStock++
+		} else {
+			stock.OutOfStock++
+		}
+	}
+	return stock
+}
@@ -1,30 +1,111 @@
 package services
 
 import (
+	"maps"
+	"sync"
+	"time"
+
+	"bookstore.com/integrity"
 	"bookstore.com/models"
 	"bookstore.com/repositories"
 )
 
 type BookSaleService struct {
 	BookSaleRepo repositories.BookSaleStore
+	bookRepo     repositories.BookStore
+	refs         *References
+
+	mu      sync.Mutex
+	changed []func(saleID int)
+}
+
+// NewBookSaleService creates a book sale service, the sales are returned with their book when
+// references are given
+func NewBookSaleService(repo repositories.BookSaleStore, bookRepo repositories.BookStore, refs *References) *BookSaleService {
+	return &BookSaleService{BookSaleRepo: repo, bookRepo: bookRepo, refs: refs}
 }
 
-func NewBookSaleService(repo repositories.BookSaleStore) *BookSaleService {
-	return &BookSaleService{BookSaleRepo: repo}
+// OnChange registers a function called after a sale was recorded or deleted
+func (s *BookSaleService) OnChange(fn func(saleID int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changed = append(s.changed, fn)
 }
 
+func (s *BookSaleService) notify(saleID int) {
+	s.mu.Lock()
+	changed := s.changed
+	s.mu.Unlock()
+	for _, fn := range changed {
+		fn(saleID)
+	}
+}
+
+// CreateBookSale records a sale, the store keeps the title of its book for the reports once
+// the book is deleted
 func (s *BookSaleService) CreateBookSale(BookSale models.BookSale) (models.BookSale, error) {
-	return s.BookSaleRepo.Create(BookSale)
+	defer integrity.Reference()()
+
+	book, err := s.bookRepo.Get(BookSale.Book.ID)
+	if err != nil {
+		return models.BookSale{}, repositories.Invalid("unknown_reference", "book %d does not exist", BookSale.Book.ID)
+	}
+	BookSale.Book = book
+	if BookSale.SoldAt.IsZero() {
+		BookSale.SoldAt = time.Now()
+	}
+	createdBookSale, err := s.BookSaleRepo.Create(BookSale)
+	if err != nil {
+		return models.BookSale{}, err
+	}
+	s.notify(createdBookSale.ID)
+	return s.refs.BookSale(createdBookSale), nil
 }
 
 func (s *BookSaleService) GetBookSale(id int) (models.BookSale, error) {
-	return s.BookSaleRepo.Get(id)
+	sale, err := s.BookSaleRepo.Get(id)
+	return s.refs.BookSale(sale), err
 }
 
 func (s *BookSaleService) DeleteBookSale(id int) error {
-	return s.BookSaleRepo.Delete(id)
+	if err := s.BookSaleRepo.Delete(id); err != nil {
+		return err
+	}
+	s.notify(id)
+	return nil
 }
 
+// SearchBookSales searches the sales, the title, author and genre filters select the sales of
+// the matching books: the title and the genre are searched like the books, the author matches
+// the first or the last name
 func (s *BookSaleService) SearchBookSales(query models.SearchCriteria) ([]models.BookSale, error) {
-	return s.BookSaleRepo.Search(query)
+	filters := maps.Clone(query.Filters)
+	if filters == nil {
+		filters = make(map[string]interface{})
+	}
+	bookFilters := make(map[string]interface{})
+	for _, name := range []string{"title", "author", "genre"} {
+		value, exists := filters[name]
+		if !exists {
+			continue
+		}
+		if _, ok := value.(string); !ok {
+			return nil, repositories.Invalid("invalid_filter", "invalid %s filter", name)
+		}
+		bookFilters[name] = value
+		delete(filters, name)
+	}
+	if len(bookFilters) > 0 {
+		books, err := NewBookService(s.bookRepo, nil, nil, s.refs).SearchBooks(models.SearchCriteria{Filters: bookFilters})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(books))
+		for i, book := range books {
+			ids[i] = book.ID
+		}
+		filters["book_id"] = ids
+	}
+	sales, err := s.BookSaleRepo.Search(models.SearchCriteria{Filters: filters})
+	return s.refs.BookSales(sales), err
 }
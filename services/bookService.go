@@ -1,50 +1,205 @@
 package services
 
 import (
-	"errors"
-	"fmt"
+	"log"
+	"maps"
+	"sync"
 
-	"bookstore.com/memory"
+	"bookstore.com/integrity"
 	"bookstore.com/models"
 	"bookstore.com/repositories"
 )
 
 type BookService struct {
-	bookRepo repositories.BookStore
+	bookRepo   repositories.BookStore
+	authorRepo repositories.AuthorStore
+	inventory  *InventoryService
+	refs       *References
+
+	mu      sync.Mutex
+	changed []func(bookID int)
 }
 
-func NewBookService(bookRepo repositories.BookStore) *BookService {
+// NewBookService creates a book service, stock changes go through the stock ledger
+// when an inventory service is given and the books are returned with their author
+// when references are given
+func NewBookService(bookRepo repositories.BookStore, authorRepo repositories.AuthorStore, inventory *InventoryService, refs *References) *BookService {
 	return &BookService{
-		bookRepo: bookRepo,
+		bookRepo:   bookRepo,
+		authorRepo: authorRepo,
+		inventory:  inventory,
+		refs:       refs,
+	}
+}
+
+// OnChange registers a function called after a book was created, updated or deleted
+func (s *BookService) OnChange(fn func(bookID int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changed = append(s.changed, fn)
+}
+
+func (s *BookService) notify(bookID int) {
+	s.mu.Lock()
+	changed := s.changed
+	s.mu.Unlock()
+	for _, fn := range changed {
+		fn(bookID)
 	}
 }
 
 // CreateBook adds a new book to the store with validation and context propagation
 func (s *BookService) CreateBook(book models.Book) (models.Book, error) {
+	defer integrity.Reference()()
 
-	_, authorExists := NewAuthorService(memory.NewInMemoryAuthorStore()).GetAuthor(book.Author.ID)
-	fmt.Println(book.Author.ID)
+	_, authorExists := s.authorRepo.Get(book.Author.ID)
 	if authorExists != nil {
-		return models.Book{}, errors.New("Author not found")
+		return models.Book{}, repositories.Invalid("unknown_reference", "author %d does not exist", book.Author.ID)
+	}
+	if s.inventory == nil || book.Stock == 0 {
+		createdBook, err := s.bookRepo.Create(book)
+		if err != nil {
+			return models.Book{}, err
+		}
+		s.notify(createdBook.ID)
+		return s.refs.Book(createdBook), nil
 	}
-	return s.bookRepo.Create(book)
+
+	// the initial stock is recorded as the first receipt of the book
+	stock := book.Stock
+	book.Stock = 0
+	createdBook, err := s.bookRepo.Create(book)
+	if err != nil {
+		return models.Book{}, err
+	}
+	s.notify(createdBook.ID)
+	movement, err := s.inventory.RecordMovement(models.StockMovement{
+		BookID:   createdBook.ID,
+		Type:     models.MovementReceipt,
+		Quantity: stock,
+		Reason:   "initial stock",
+	})
+	if err != nil {
+		// the book without its initial stock is not kept, the client sees the creation failed
+		if deleteErr := s.bookRepo.Delete(createdBook.ID); deleteErr != nil {
+			log.Printf("BookService: removing book %d without its initial stock error: %v", createdBook.ID, deleteErr)
+		}
+		s.notify(createdBook.ID)
+		return models.Book{}, err
+	}
+	// the movement wrote the stock, the book is answered at the version it left
+	if createdBook, err = s.bookRepo.Get(createdBook.ID); err != nil {
+		return models.Book{}, err
+	}
+	createdBook.Stock = movement.Balance
+	return s.refs.Book(createdBook), nil
 }
 
 // GetBookByID retrieves a book by its ID, passing context to the repository
 func (s *BookService) GetBookByID(id int) (models.Book, error) {
-	return s.bookRepo.Get(id)
+	book, err := s.bookRepo.Get(id)
+	return s.refs.Book(book), err
 }
 
 // UpdateBook updates an existing book in the store
+// a different stock is recorded in the ledger as an adjustment
 func (s *BookService) UpdateBook(book models.Book) (models.Book, error) {
+	defer integrity.Reference()()
+
+	if _, err := s.authorRepo.Get(book.Author.ID); err != nil {
+		return models.Book{}, repositories.Invalid("unknown_reference", "author %d does not exist", book.Author.ID)
+	}
+	if s.inventory == nil {
+		return s.update(book)
+	}
 
-	return s.bookRepo.Update(book)
+	existing, err := s.bookRepo.Get(book.ID)
+	if err != nil {
+		return models.Book{}, err
+	}
+	if book.Version != 0 && book.Version != existing.Version {
+		return models.Book{}, repositories.ErrVersionConflict
+	}
+	// the other fields are written at the version read, keeping the stock of the ledger, so
+	// an edit or a movement since then is a conflict; the new stock is then an adjustment
+	stock := book.Stock
+	book.Stock = existing.Stock
+	book.Version = existing.Version
+	updatedBook, err := s.bookRepo.Update(book)
+	if err != nil {
+		return models.Book{}, err
+	}
+	if stock != existing.Stock {
+		if _, err := s.inventory.RecordMovement(models.StockMovement{
+			BookID:   book.ID,
+			Type:     models.MovementAdjustment,
+			Quantity: stock - existing.Stock,
+			Reason:   "book update",
+		}); err != nil {
+			// the update is undone unless the book changed again meanwhile
+			existing.Version = updatedBook.Version
+			if _, rollbackErr := s.bookRepo.Update(existing); rollbackErr != nil {
+				log.Printf("BookService: undoing the update of book %d error: %v", book.ID, rollbackErr)
+				s.notify(book.ID)
+			}
+			return models.Book{}, err
+		}
+		if updatedBook, err = s.bookRepo.Get(book.ID); err != nil {
+			return models.Book{}, err
+		}
+	}
+	s.notify(updatedBook.ID)
+	return s.refs.Book(updatedBook), nil
+}
+
+func (s *BookService) update(book models.Book) (models.Book, error) {
+	updatedBook, err := s.bookRepo.Update(book)
+	if err != nil {
+		return models.Book{}, err
+	}
+	s.notify(updatedBook.ID)
+	return s.refs.Book(updatedBook), nil
 }
 
 func (s *BookService) DeleteBook(id int) error {
-	return s.bookRepo.Delete(id)
+	err := integrity.Delete("books", id, func() error {
+		return s.bookRepo.Delete(id)
+	})
+	if err != nil {
+		return err
+	}
+	s.notify(id)
+	return nil
 }
 
+// SearchBooks searches the books, the author filter matches the first or the last name
+// of the author
 func (s *BookService) SearchBooks(query models.SearchCriteria) ([]models.Book, error) {
-	return s.bookRepo.Search(query)
+	if name, exists := query.Filters["author"]; exists && s.refs != nil {
+		name, ok := name.(string)
+		if !ok {
+			return nil, repositories.Invalid("invalid_filter", "invalid author filter")
+		}
+		ids, err := s.refs.AuthorIDs(name)
+		if err != nil {
+			return nil, err
+		}
+		filters := maps.Clone(query.Filters)
+		delete(filters, "author")
+		filters["author_id"] = ids
+		query.Filters = filters
+	}
+	books, err := s.bookRepo.Search(query)
+	return s.refs.Books(books), err
+}
+
+// AuthorBooks searches the books of an author
+func (s *BookService) AuthorBooks(authorID int, query models.SearchCriteria) ([]models.Book, error) {
+	if _, err := s.authorRepo.Get(authorID); err != nil {
+		return nil, err
+	}
+	filters := maps.Clone(query.Filters)
+	delete(filters, "author")
+	filters["author_id"] = authorID
+	return s.SearchBooks(models.SearchCriteria{Filters: filters})
 }
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+	"bookstore.com/search"
+)
+
+// catalogFields are the indexed fields of a book and their weight in the relevance
+var catalogFields = map[string]float64{
+	"title":       3,
+	"isbn":        3,
+	"author":      2,
+	"genres":      1.5,
+	"description": 1,
+}
+
+// CatalogSearchService searches the books by relevance over their title, author, genres,
+// description and ISBN. The index is built at startup and kept up to date by IndexBook and
+// IndexAuthorBooks, called whenever a book or an author changes.
+type CatalogSearchService struct {
+	bookRepo   repositories.BookStore
+	authorRepo repositories.AuthorStore
+	refs       *References
+	index      *search.Index
+}
+
+func NewCatalogSearchService(bookRepo repositories.BookStore, authorRepo repositories.AuthorStore, refs *References) *CatalogSearchService {
+	s := &CatalogSearchService{
+		bookRepo:   bookRepo,
+		authorRepo: authorRepo,
+		refs:       refs,
+		index:      search.NewIndex(catalogFields),
+	}
+	books, err := bookRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{}})
+	if err != nil {
+		log.Printf("CatalogSearchService: indexing error: %v", err)
+	}
+	for _, book := range books {
+		s.index.Add(book.ID, s.document(book))
+	}
+	return s
+}
+
+// IndexBook indexes the current version of a book, or removes it when it was deleted
+func (s *CatalogSearchService) IndexBook(id int) {
+	book, err := s.bookRepo.Get(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		s.index.Remove(id)
+		return
+	}
+	if err != nil {
+		log.Printf("CatalogSearchService: indexing book %d error: %v", id, err)
+		return
+	}
+	s.index.Add(book.ID, s.document(book))
+}
+
+// IndexAuthorBooks indexes the books of an author again after the author changed
+func (s *CatalogSearchService) IndexAuthorBooks(authorID int) {
+	books, err := s.bookRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"author_id": authorID}})
+	if err != nil {
+		log.Printf("CatalogSearchService: indexing author %d error: %v", authorID, err)
+		return
+	}
+	for _, book := range books {
+		s.index.Add(book.ID, s.document(book))
+	}
+}
+
+// Search returns a page of the books matching the query, the most relevant first. A query
+// made of stop words only matches no book.
+func (s *CatalogSearchService) Search(query string, limit, offset int) (models.SearchResults, error) {
+	hits := s.index.Search(query)
+	results := models.SearchResults{Query: query, Total: len(hits), Offset: offset, Hits: []models.SearchHit{}}
+	if offset >= len(hits) {
+		return results, nil
+	}
+	hits = hits[offset:min(len(hits), offset+limit)]
+	for _, hit := range hits {
+		book, err := s.bookRepo.Get(hit.ID)
+		if err != nil {
+			continue
+		}
+		results.Hits = append(results.Hits, models.SearchHit{
+			Book:       s.refs.Book(book),
+			Score:      hit.Score,
+			Highlights: s.index.Highlights(hit.ID, query),
+		})
+	}
+	return results, nil
+}
+
+// document returns the indexed fields of a book
+func (s *CatalogSearchService) document(book models.Book) map[string]string {
+	author, _ := s.authorRepo.Get(book.Author.ID)
+	return map[string]string{
+		"title":       book.Title,
+		"isbn":        book.ISBN,
+		"author":      authorName(author),
+		"genres":      strings.Join(book.Genres, ", "),
+		"description": book.Description,
+	}
+}
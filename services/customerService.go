@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bookstore.com/integrity"
 	"bookstore.com/models"
 	"bookstore.com/repositories"
 )
@@ -26,7 +27,9 @@ func (s *CustomerService) UpdateCustomer(customer models.Customer) (models.Custo
 }
 
 func (s *CustomerService) DeleteCustomer(id int) error {
-	return s.customerRepo.Delete(id)
+	return integrity.Delete("customers", id, func() error {
+		return s.customerRepo.Delete(id)
+	})
 }
 
 func (s *CustomerService) SearchCustomers(query models.SearchCriteria) ([]models.Customer, error) {
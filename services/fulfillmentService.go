@@ -0,0 +1,335 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+// FulfillmentConfig configures the expected ship dates and how often pre-orders are checked
+type FulfillmentConfig struct {
+	HandlingTime time.Duration // delay between the allocation of an item and its shipment
+	LeadTime     time.Duration // expected delay between a purchase order and its delivery
+	ScanInterval time.Duration // interval between the checks for newly published titles
+}
+
+var DefaultFulfillmentConfig = FulfillmentConfig{
+	HandlingTime: 24 * time.Hour,
+	LeadTime:     7 * 24 * time.Hour,
+	ScanInterval: 15 * time.Minute,
+}
+
+// FulfillmentService allocates the orders to the stock. Items of titles not published yet are
+// preordered, items out of stock are backordered, both are allocated as soon as the title
+// publishes or the stock arrives, the oldest orders first.
+type FulfillmentService struct {
+	orderRepo    repositories.OrderStore
+	customerRepo repositories.CustomerStore
+	purchaseRepo repositories.PurchaseOrderStore
+	bookRepo     repositories.BookStore
+	inventory    *InventoryService
+	refs         *References
+	config       FulfillmentConfig
+
+	mu   sync.Mutex
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewFulfillmentService creates the fulfillment service, pending items are allocated
+// whenever the inventory records new stock and the backorders are returned with their
+// customer and books when references are given
+func NewFulfillmentService(orderRepo repositories.OrderStore, customerRepo repositories.CustomerStore, purchaseRepo repositories.PurchaseOrderStore, bookRepo repositories.BookStore, inventory *InventoryService, refs *References, config FulfillmentConfig) *FulfillmentService {
+	s := &FulfillmentService{
+		orderRepo:    orderRepo,
+		customerRepo: customerRepo,
+		purchaseRepo: purchaseRepo,
+		bookRepo:     bookRepo,
+		inventory:    inventory,
+		refs:         refs,
+		config:       config,
+		stop:         make(chan struct{}),
+	}
+	inventory.OnRestock(func(bookID int) {
+		if _, err := s.Fulfill(time.Now()); err != nil {
+			log.Printf("FulfillmentService: restock of book %d error: %v", bookID, err)
+		}
+	})
+	return s
+}
+
+// Place allocates the items of a new order. An unpublished title is accepted when the book is
+// preorderable and missing stock when the book is backorderable, the order is refused otherwise.
+func (s *FulfillmentService) Place(order models.Order, address models.Address, now time.Time) (models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the items are copied, the created order shares them with the store
+	order.Items = slices.Clone(order.Items)
+	for i := range order.Items {
+		book, err := s.bookRepo.Get(order.Items[i].Book.ID)
+		if err != nil {
+			return models.Order{}, err
+		}
+		if order.Items[i].Quantity <= 0 {
+			return models.Order{}, repositories.Invalid("invalid_quantity", "ordered quantity must be positive")
+		}
+		if book.PublishedAt.After(now) && !book.Preorderable {
+			return models.Order{}, repositories.Conflict("not_published", "book %d is not published until %s", book.ID, book.PublishedAt.Format(time.DateOnly))
+		}
+		order.Items[i].Pending = order.Items[i].Quantity
+		order.Items[i].Status = ""
+		order.Items[i].ExpectedShipAt = time.Time{}
+	}
+	order.Allocations = nil
+	return s.allocate(order, address, now, true)
+}
+
+// PlaceItem allocates the last item of an order, added after the order was placed, with the
+// rules of the items of a new order
+func (s *FulfillmentService) PlaceItem(order models.Order, address models.Address, now time.Time) (models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order.Items = slices.Clone(order.Items)
+	item := &order.Items[len(order.Items)-1]
+	book, err := s.bookRepo.Get(item.Book.ID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if book.PublishedAt.After(now) && !book.Preorderable {
+		return models.Order{}, repositories.Conflict("not_published", "book %d is not published until %s", book.ID, book.PublishedAt.Format(time.DateOnly))
+	}
+	item.Pending = item.Quantity
+	item.Status = ""
+	item.ExpectedShipAt = time.Time{}
+	return s.allocate(order, address, now, true)
+}
+
+// Fulfill allocates the pending items of the published titles with the available stock,
+// the oldest orders first, and returns the orders that received stock
+func (s *FulfillmentService) Fulfill(now time.Time) ([]models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orders, err := s.pendingOrders(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var fulfilled []models.Order
+	var firstErr error
+	for _, order := range orders {
+		before := pendingQuantity(order)
+		updated, err := s.allocate(order, s.customerAddress(order.Customer.ID), now, false)
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			// the order changed since it was listed, its latest version is allocated instead
+			if order, err = s.orderRepo.Get(order.ID); err == nil {
+				before = pendingQuantity(order)
+				updated, err = s.allocate(order, s.customerAddress(order.Customer.ID), now, false)
+			}
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("order %d: %w", order.ID, err)
+			}
+			continue
+		}
+		if pendingQuantity(updated) < before {
+			fulfilled = append(fulfilled, updated)
+		}
+	}
+	return fulfilled, firstErr
+}
+
+// Backorders lists the orders with preordered or backordered items, optionally of a single book
+func (s *FulfillmentService) Backorders(query models.SearchCriteria) ([]models.Order, error) {
+	bookID := 0
+	switch value := query.Filters["book_id"].(type) {
+	case float64:
+		bookID = int(value)
+	case int:
+		bookID = value
+	}
+	orders, err := s.pendingOrders(bookID)
+	return s.refs.Orders(orders), err
+}
+
+// Start allocates the pre-orders of the titles published since the previous check
+// at the configured interval in a background goroutine
+func (s *FulfillmentService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.config.ScanInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.Fulfill(time.Now()); err != nil {
+					log.Printf("FulfillmentService: scan error: %v", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *FulfillmentService) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// allocate records the sales of the pending items of the published titles and refreshes the
+// statuses and expected ship dates of the order. Books already on backorder take whatever
+// stock is available, a new order of a book that is not backorderable needs the full quantity.
+func (s *FulfillmentService) allocate(order models.Order, address models.Address, now time.Time, placing bool) (models.Order, error) {
+	// the items and allocations are copied, the order read from the store shares them
+	order.Items = slices.Clone(order.Items)
+	order.Allocations = slices.Clone(order.Allocations)
+	books := make(map[int]models.Book)
+	partial := make(map[int]bool)
+	var requested []models.AllocationItem
+	for _, item := range order.Items {
+		book, err := s.bookRepo.Get(item.Book.ID)
+		if err != nil {
+			return models.Order{}, err
+		}
+		books[book.ID] = book
+		if item.Pending <= 0 || book.PublishedAt.After(now) {
+			continue
+		}
+		requested = append(requested, models.AllocationItem{BookID: book.ID, Quantity: item.Pending})
+		partial[book.ID] = book.Backorderable || !placing
+	}
+
+	previous := len(order.Allocations)
+	allocated := make(map[int]int)
+	if len(requested) > 0 {
+		allocations, quantities, err := s.inventory.RecordSales(requested, partial, address, fmt.Sprintf("order %d", order.ID), "")
+		if err != nil {
+			return models.Order{}, err
+		}
+		order.Allocations = append(order.Allocations, allocations...)
+		allocated = quantities
+	}
+
+	restocks := make(map[int]time.Time)
+	order.Status = models.OrderConfirmed
+	order.ExpectedShipAt = time.Time{}
+	for i := range order.Items {
+		item := &order.Items[i]
+		book := books[item.Book.ID]
+		quantity := min(item.Pending, allocated[book.ID])
+		allocated[book.ID] -= quantity
+		item.Pending -= quantity
+
+		switch {
+		case item.Pending == 0:
+			if quantity > 0 || item.ExpectedShipAt.IsZero() {
+				item.ExpectedShipAt = now.Add(s.config.HandlingTime)
+			}
+			item.Status = models.ItemAllocated
+		case book.PublishedAt.After(now):
+			item.Status = models.ItemPreordered
+			item.ExpectedShipAt = book.PublishedAt.Add(s.config.HandlingTime)
+			if order.Status != models.OrderBackordered {
+				order.Status = models.OrderPreordered
+			}
+		default:
+			if _, exists := restocks[book.ID]; !exists {
+				restocks[book.ID] = s.expectedRestock(book.ID, now)
+			}
+			item.Status = models.ItemBackordered
+			item.ExpectedShipAt = restocks[book.ID].Add(s.config.HandlingTime)
+			order.Status = models.OrderBackordered
+		}
+		if item.ExpectedShipAt.After(order.ExpectedShipAt) {
+			order.ExpectedShipAt = item.ExpectedShipAt
+		}
+	}
+	updatedOrder, err := s.orderRepo.Update(order)
+	if errors.Is(err, repositories.ErrVersionConflict) && len(order.Allocations) > previous {
+		// the order changed since it was read, the sales of this allocation are returned
+		if cancelErr := s.inventory.CancelSales(order.Allocations[previous:], fmt.Sprintf("order %d changed", order.ID), ""); cancelErr != nil {
+			log.Printf("FulfillmentService: returning the stock of order %d error: %v", order.ID, cancelErr)
+		}
+	}
+	return updatedOrder, err
+}
+
+// expectedRestock estimates when stock of a book arrives: the earliest delivery expected from
+// the purchase orders sent for it, or a new purchase order lead time when none is open
+func (s *FulfillmentService) expectedRestock(bookID int, now time.Time) time.Time {
+	expected := now.Add(s.config.LeadTime)
+	purchases, err := s.purchaseRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return expected
+	}
+	for _, purchase := range purchases {
+		if purchase.Status != models.PurchaseOrderSent && purchase.Status != models.PurchaseOrderPartiallyReceived {
+			continue
+		}
+		for _, line := range purchase.Lines {
+			if line.Book.ID != bookID || line.Received >= line.Quantity {
+				continue
+			}
+			arrival := purchase.SentAt.Add(s.config.LeadTime)
+			if arrival.Before(now) {
+				arrival = now
+			}
+			if arrival.Before(expected) {
+				expected = arrival
+			}
+		}
+	}
+	return expected
+}
+
+// pendingOrders returns the orders with pending items, of the given book when bookID is
+// not 0, the oldest first
+func (s *FulfillmentService) pendingOrders(bookID int) ([]models.Order, error) {
+	orders, err := s.orderRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]models.Order, 0)
+	for _, order := range orders {
+		for _, item := range order.Items {
+			if item.Pending > 0 && (bookID == 0 || item.Book.ID == bookID) {
+				pending = append(pending, order)
+				break
+			}
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].CreatedAt.Equal(pending[j].CreatedAt) {
+			return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+		}
+		return pending[i].ID < pending[j].ID
+	})
+	return pending, nil
+}
+
+func (s *FulfillmentService) customerAddress(customerID int) models.Address {
+	customer, err := s.customerRepo.Get(customerID)
+	if err != nil {
+		return models.Address{}
+	}
+	return customer.Address
+}
+
+func pendingQuantity(order models.Order) int {
+	quantity := 0
+	for _, item := range order.Items {
+		quantity += item.Pending
+	}
+	return quantity
+}
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+// InventoryService keeps the stock ledger. Every movement happens in a warehouse,
+// the stock levels hold the balance per warehouse and Book.Stock the total across warehouses.
+type InventoryService struct {
+	mu            sync.Mutex
+	movementRepo  repositories.StockMovementStore
+	bookRepo      repositories.BookStore
+	levelRepo     repositories.StockLevelStore
+	warehouseRepo repositories.WarehouseStore
+	strategy      string
+	restocked     []func(bookID int)
+}
+
+// NewInventoryService creates the inventory service, orders are allocated to the warehouses
+// with the given strategy (models.AllocateByProximity or models.AllocateByAvailability)
+func NewInventoryService(movementRepo repositories.StockMovementStore, bookRepo repositories.BookStore, levelRepo repositories.StockLevelStore, warehouseRepo repositories.WarehouseStore, strategy string) *InventoryService {
+	return &InventoryService{
+		movementRepo:  movementRepo,
+		bookRepo:      bookRepo,
+		levelRepo:     levelRepo,
+		warehouseRepo: warehouseRepo,
+		strategy:      strategy,
+	}
+}
+
+// OnRestock registers a function called after a movement added stock of a book
+func (s *InventoryService) OnRestock(fn func(bookID int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restocked = append(s.restocked, fn)
+}
+
+// RecordMovement validates a movement, appends it to the ledger and updates the book stock
+func (s *InventoryService) RecordMovement(movement models.StockMovement) (models.StockMovement, error) {
+	created, restocked, err := s.recordMovement(movement)
+	if err != nil {
+		return models.StockMovement{}, err
+	}
+	// the listeners run once the ledger is unlocked, they may record movements themselves
+	for _, fn := range restocked {
+		fn(created.BookID)
+	}
+	return created, nil
+}
+
+func (s *InventoryService) recordMovement(movement models.StockMovement) (models.StockMovement, []func(int), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if movement.Type == models.MovementTransferOut || movement.Type == models.MovementTransferIn {
+		return models.StockMovement{}, nil, repositories.Invalid("invalid_movement_type", "transfers are recorded with Transfer")
+	}
+	var err error
+	if movement.WarehouseID, err = s.resolveWarehouse(movement.WarehouseID); err != nil {
+		return models.StockMovement{}, nil, err
+	}
+	created, err := s.record(movement)
+	if err != nil || created.Delta() <= 0 {
+		return created, nil, err
+	}
+	return created, s.restocked, nil
+}
+
+// RecordSales allocates the requested books to the warehouses and records a sale movement per
+// allocated item, either all of them or none. Books in partial are allocated up to their
+// available stock, the others must be fully available. It returns the allocations and the
+// quantity allocated per book.
+func (s *InventoryService) RecordSales(requested []models.AllocationItem, partial map[int]bool, address models.Address, reason, user string) ([]models.OrderAllocation, map[int]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var merged []models.AllocationItem
+	positions := make(map[int]int)
+	for _, item := range requested {
+		if _, err := s.bookRepo.Get(item.BookID); err != nil {
+			return nil, nil, repositories.Invalid("unknown_reference", "book %d does not exist", item.BookID)
+		}
+		if item.Quantity <= 0 {
+			return nil, nil, repositories.Invalid("invalid_quantity", "ordered quantity must be positive")
+		}
+		if i, exists := positions[item.BookID]; exists {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		positions[item.BookID] = len(merged)
+		merged = append(merged, item)
+	}
+
+	warehouses, err := s.warehouseRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(warehouses) == 0 {
+		warehouses = []models.Warehouse{{ID: 0}}
+	}
+	available := make(map[int]map[int]int)
+	total := make(map[int]int)
+	for _, warehouse := range warehouses {
+		available[warehouse.ID] = make(map[int]int)
+		for _, item := range merged {
+			level, err := s.levelRepo.Get(warehouse.ID, item.BookID)
+			if err != nil {
+				return nil, nil, err
+			}
+			available[warehouse.ID][item.BookID] = level.Quantity
+			total[item.BookID] += level.Quantity
+		}
+	}
+
+	allocated := make(map[int]int)
+	var allocatable []models.AllocationItem
+	for _, item := range merged {
+		if partial[item.BookID] {
+			item.Quantity = min(item.Quantity, total[item.BookID])
+		}
+		if item.Quantity > 0 {
+			allocatable = append(allocatable, item)
+			allocated[item.BookID] = item.Quantity
+		}
+	}
+	if len(allocatable) == 0 {
+		return nil, allocated, nil
+	}
+
+	allocations, err := allocate(allocatable, warehouses, available, address, s.strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, allocation := range allocations {
+		for _, item := range allocation.Items {
+			if _, err := s.record(models.StockMovement{
+				BookID:      item.BookID,
+				WarehouseID: allocation.WarehouseID,
+				Type:        models.MovementSale,
+				Quantity:    item.Quantity,
+				Reason:      reason,
+				User:        user,
+			}); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return allocations, allocated, nil
+}
+
+// CancelSales returns the stock of allocations recorded by RecordSales that could not be kept,
+// e.g. when the order changed meanwhile, as return movements. The returned stock was taken a
+// moment ago, the restock listeners are not called.
+func (s *InventoryService) CancelSales(allocations []models.OrderAllocation, reason, user string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, allocation := range allocations {
+		for _, item := range allocation.Items {
+			if _, err := s.record(models.StockMovement{
+				BookID:      item.BookID,
+				WarehouseID: allocation.WarehouseID,
+				Type:        models.MovementReturn,
+				Quantity:    item.Quantity,
+				Reason:      reason,
+				User:        user,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Transfer moves stock of a book from a warehouse to another one, recorded as a
+// transfer_out and a transfer_in movement
+func (s *InventoryService) Transfer(transfer models.StockTransfer) (models.StockTransfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if transfer.FromWarehouseID == transfer.ToWarehouseID {
+		return models.StockTransfer{}, repositories.Invalid("invalid_transfer", "a transfer needs two different warehouses")
+	}
+	if transfer.ToWarehouseID == 0 {
+		return models.StockTransfer{}, repositories.Invalid("invalid_transfer", "the destination warehouse is required")
+	}
+	if _, err := s.warehouseRepo.Get(transfer.ToWarehouseID); err != nil {
+		return models.StockTransfer{}, repositories.Invalid("unknown_reference", "warehouse %d does not exist", transfer.ToWarehouseID)
+	}
+	if transfer.FromWarehouseID != 0 {
+		if _, err := s.warehouseRepo.Get(transfer.FromWarehouseID); err != nil {
+			return models.StockTransfer{}, repositories.Invalid("unknown_reference", "warehouse %d does not exist", transfer.FromWarehouseID)
+		}
+	}
+
+	out, err := s.record(models.StockMovement{
+		BookID:      transfer.BookID,
+		WarehouseID: transfer.FromWarehouseID,
+		Type:        models.MovementTransferOut,
+		Quantity:    transfer.Quantity,
+		Reason:      transfer.Reason,
+		User:        transfer.User,
+	})
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	in, err := s.record(models.StockMovement{
+		BookID:      transfer.BookID,
+		WarehouseID: transfer.ToWarehouseID,
+		Type:        models.MovementTransferIn,
+		Quantity:    transfer.Quantity,
+		Reason:      transfer.Reason,
+		User:        transfer.User,
+	})
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	transfer.Movements = []models.StockMovement{out, in}
+	return transfer, nil
+}
+
+// AssignUnassigned transfers the stock recorded before any warehouse existed, held in
+// warehouse 0, to a new warehouse where it can be allocated and shipped
+func (s *InventoryService) AssignUnassigned(warehouseID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	levels, err := s.levelRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"warehouse_id": 0}})
+	if err != nil {
+		return err
+	}
+	for _, level := range levels {
+		if level.Quantity <= 0 {
+			continue
+		}
+		for _, movement := range []models.StockMovement{
+			{BookID: level.BookID, WarehouseID: 0, Type: models.MovementTransferOut, Quantity: level.Quantity, Reason: "unassigned stock"},
+			{BookID: level.BookID, WarehouseID: warehouseID, Type: models.MovementTransferIn, Quantity: level.Quantity, Reason: "unassigned stock"},
+		} {
+			if _, err := s.record(movement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// StockLevels returns the stock of a book in every warehouse it was stocked in
+func (s *InventoryService) StockLevels(bookID int) ([]models.StockLevel, error) {
+	if _, err := s.bookRepo.Get(bookID); err != nil {
+		return nil, err
+	}
+	return s.levelRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"book_id": bookID}})
+}
+
+// WarehouseStock returns the stock levels of a warehouse
+func (s *InventoryService) WarehouseStock(warehouseID int) ([]models.StockLevel, error) {
+	if _, err := s.warehouseRepo.Get(warehouseID); err != nil {
+		return nil, err
+	}
+	return s.levelRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"warehouse_id": warehouseID}})
+}
+
+func (s *InventoryService) record(movement models.StockMovement) (models.StockMovement, error) {
+	switch movement.Type {
+	case models.MovementReceipt, models.MovementSale, models.MovementReturn, models.MovementDamage,
+		models.MovementTransferOut, models.MovementTransferIn:
+		if movement.Quantity <= 0 {
+			return models.StockMovement{}, repositories.Invalid("invalid_quantity", "quantity must be positive")
+		}
+	case models.MovementAdjustment:
+		if movement.Quantity == 0 {
+			return models.StockMovement{}, repositories.Invalid("invalid_quantity", "adjustment quantity must not be zero")
+		}
+	default:
+		return models.StockMovement{}, repositories.Invalid("invalid_movement_type", "unknown movement type: %s", movement.Type)
+	}
+	if movement.UnitCost < 0 {
+		return models.StockMovement{}, repositories.Invalid("invalid_unit_cost", "unit cost must not be negative")
+	}
+
+	book, err := s.bookRepo.Get(movement.BookID)
+	if err != nil {
+		return models.StockMovement{}, repositories.Invalid("unknown_reference", "book %d does not exist", movement.BookID)
+	}
+	level, err := s.levelRepo.Get(movement.WarehouseID, movement.BookID)
+	if err != nil {
+		return models.StockMovement{}, err
+	}
+
+	movement.Balance = book.Stock + movement.Delta()
+	movement.WarehouseBalance = level.Quantity + movement.Delta()
+	if movement.WarehouseBalance < 0 {
+		return models.StockMovement{}, repositories.Conflict("insufficient_stock", "insufficient stock for book %d in warehouse %d: %d available", book.ID, movement.WarehouseID, level.Quantity)
+	}
+	if movement.CreatedAt.IsZero() {
+		movement.CreatedAt = time.Now()
+	}
+
+	created, err := s.movementRepo.Create(movement)
+	if err != nil {
+		return models.StockMovement{}, err
+	}
+	level.Quantity = created.WarehouseBalance
+	if _, err := s.levelRepo.Save(level); err != nil {
+		return models.StockMovement{}, err
+	}
+	// the stock belongs to the ledger, written under its lock: when the other fields of the
+	// book changed since it was read, the stock is written on their latest version
+	for {
+		book.Stock = created.Balance
+		_, err := s.bookRepo.Update(book)
+		if err == nil {
+			return created, nil
+		}
+		if !errors.Is(err, repositories.ErrVersionConflict) {
+			return models.StockMovement{}, err
+		}
+		if book, err = s.bookRepo.Get(book.ID); err != nil {
+			return models.StockMovement{}, err
+		}
+	}
+}
+
+// resolveWarehouse checks the warehouse of a movement. Movements without a warehouse go to
+// the first warehouse, or to warehouse 0 while no warehouse exists.
+func (s *InventoryService) resolveWarehouse(id int) (int, error) {
+	if id != 0 {
+		if _, err := s.warehouseRepo.Get(id); err != nil {
+			return 0, err
+		}
+		return id, nil
+	}
+	warehouses, err := s.warehouseRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil || len(warehouses) == 0 {
+		return 0, err
+	}
+	return warehouses[0].ID, nil
+}
+
+func (s *InventoryService) GetMovement(id int) (models.StockMovement, error) {
+	return s.movementRepo.Get(id)
+}
+
+// BookMovements returns the ledger of a book, optionally filtered by type, from and to
+func (s *InventoryService) BookMovements(bookID int, query models.SearchCriteria) ([]models.StockMovement, error) {
+	if _, err := s.bookRepo.Get(bookID); err != nil {
+		return nil, err
+	}
+	if query.Filters == nil {
+		query.Filters = make(map[string]interface{})
+	}
+	query.Filters["book_id"] = bookID
+	return s.movementRepo.Search(query)
+}
+
+// costLayer is a quantity of stock received at the same unit cost
+type costLayer struct {
+	quantity int
+	unitCost float64
+}
+
+// Valuation values the stock of every book at the given time, using FIFO cost layers
+// or the weighted average cost of the receipts
+func (s *InventoryService) Valuation(method string, at time.Time) (models.InventoryValuation, error) {
+	if method == "" {
+		method = models.ValuationFIFO
+	}
+	if method != models.ValuationFIFO && method != models.ValuationAverage {
+		return models.InventoryValuation{}, repositories.Invalid("invalid_valuation_method", "unknown valuation method: %s", method)
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	movements, err := s.movementRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"to": at}})
+	if err != nil {
+		return models.InventoryValuation{}, err
+	}
+	layers := make(map[int][]costLayer)
+	for _, movement := range movements {
+		// transfers move stock between warehouses without changing its cost
+		if movement.Type == models.MovementTransferOut || movement.Type == models.MovementTransferIn {
+			continue
+		}
+		layers[movement.BookID] = applyMovement(layers[movement.BookID], movement, method)
+	}
+
+	valuation := models.InventoryValuation{Method: method, At: at, Books: make([]models.BookValuation, 0)}
+	for bookID, bookLayers := range layers {
+		bookValuation := models.BookValuation{BookID: bookID}
+		if book, err := s.bookRepo.Get(bookID); err == nil {
+			bookValuation.Title = book.Title
+		}
+		for _, layer := range bookLayers {
+			bookValuation.Quantity += layer.quantity
+			bookValuation.Value += float64(layer.quantity) * layer.unitCost
+		}
+		if bookValuation.Quantity > 0 {
+			bookValuation.UnitCost = bookValuation.Value / float64(bookValuation.Quantity)
+		}
+		valuation.TotalQuantity += bookValuation.Quantity
+		valuation.TotalValue += bookValuation.Value
+		valuation.Books = append(valuation.Books, bookValuation)
+	}
+	sort.Slice(valuation.Books, func(i, j int) bool {
+		return valuation.Books[i].BookID < valuation.Books[j].BookID
+	})
+
+	return valuation, nil
+}
+
+// applyMovement adds or consumes cost layers. Inflows without a unit cost enter at the
+// current average cost, outflows consume the oldest layers first. With the average
+// method the layers are merged into a single one after each inflow.
+func applyMovement(layers []costLayer, movement models.StockMovement, method string) []costLayer {
+	delta := movement.Delta()
+	if delta > 0 {
+		unitCost := movement.UnitCost
+		if unitCost == 0 {
+			unitCost = averageCost(layers)
+		}
+		layers = append(layers, costLayer{quantity: delta, unitCost: unitCost})
+		if method == models.ValuationAverage {
+			quantity := 0
+			for _, layer := range layers {
+				quantity += layer.quantity
+			}
+			layers = []costLayer{{quantity: quantity, unitCost: averageCost(layers)}}
+		}
+		return layers
+	}
+
+	remaining := -delta
+	for len(layers) > 0 && remaining > 0 {
+		if layers[0].quantity > remaining {
+			layers[0].quantity -= remaining
+			remaining = 0
+		} else {
+			remaining -= layers[0].quantity
+			layers = layers[1:]
+		}
+	}
+	return layers
+}
+
+func averageCost(layers []costLayer) float64 {
+	quantity, value := 0, 0.0
+	for _, layer := range layers {
+		quantity += layer.quantity
+		value += float64(layer.quantity) * layer.unitCost
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return value / float64(quantity)
+}
@@ -1,8 +1,6 @@
 package services
 
 import (
-	"errors"
-
 	"bookstore.com/memory"
 	"bookstore.com/models"
 	"bookstore.com/repositories"
@@ -16,11 +14,15 @@ func NewOrderItemService(repo repositories.OrderItemStore) *OrderItemService {
 	return &OrderItemService{orderItemRepo: repo}
 }
 
+// CreateOrderItem adds an order item with the snapshot of the title and the current price
+// of its book
 func (s *OrderItemService) CreateOrderItem(orderItem models.OrderItem) (models.OrderItem, error) {
-	_, bookExists := NewBookService(memory.NewInMemoryBookStore()).GetBookByID(orderItem.Book.ID)
+	book, bookExists := NewBookService(memory.NewInMemoryBookStore(), memory.NewInMemoryAuthorStore(), nil, nil).GetBookByID(orderItem.Book.ID)
 	if bookExists != nil {
-		return models.OrderItem{}, errors.New("book not found")
+		return models.OrderItem{}, repositories.Invalid("unknown_reference", "book %d does not exist", orderItem.Book.ID)
 	}
+	orderItem.Title = book.Title
+	orderItem.UnitPrice = book.Price
 
 	return s.orderItemRepo.Create(orderItem)
 }
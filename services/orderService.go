@@ -1,47 +1,231 @@
 package services
 
 import (
-	"errors"
+	"maps"
+	"slices"
+	"time"
 
-	"bookstore.com/memory"
+	"bookstore.com/integrity"
 	"bookstore.com/models"
 	"bookstore.com/repositories"
 )
 
 type OrderService struct {
-	orderRepo repositories.OrderStore
+	orderRepo    repositories.OrderStore
+	customerRepo repositories.CustomerStore
+	bookRepo     repositories.BookStore
+	fulfillment  *FulfillmentService
+	refs         *References
 }
 
-func NewOrderService(repo repositories.OrderStore) *OrderService {
-	return &OrderService{orderRepo: repo}
+// NewOrderService creates an order service, new orders are allocated to the stock
+// when a fulfillment service is given and the orders are returned with their customer
+// and books when references are given
+func NewOrderService(repo repositories.OrderStore, customerRepo repositories.CustomerStore, bookRepo repositories.BookStore, fulfillment *FulfillmentService, refs *References) *OrderService {
+	return &OrderService{orderRepo: repo, customerRepo: customerRepo, bookRepo: bookRepo, fulfillment: fulfillment, refs: refs}
 }
 
+// CreateOrder creates an order, its items snapshot the title and the price of their book
+// so the order keeps the price it was placed at
 func (s *OrderService) CreateOrder(order models.Order) (models.Order, error) {
-	_, customerExists := NewCustomerService(memory.NewInMemoryCustomerStore()).GetCustomer(order.Customer.ID)
-	for _, item := range order.Items {
-		_, bookFound := NewOrderItemService(memory.NewInMemoryOrderItemStore()).CreateOrderItem(item)
-		if bookFound != nil {
-			return models.Order{}, errors.New("Some Books does not exist")
+	defer integrity.Reference()()
+
+	customer, customerExists := s.customerRepo.Get(order.Customer.ID)
+	order.Items = slices.Clone(order.Items)
+	for i := range order.Items {
+		if err := s.snapshot(&order.Items[i]); err != nil {
+			return models.Order{}, err
 		}
+		order.Items[i].ID = i + 1
 	}
+	order.TotalPrice = orderTotal(order.Items)
 	if customerExists != nil {
-		return models.Order{}, errors.New("customer not found")
+		return models.Order{}, repositories.Invalid("unknown_reference", "customer %d does not exist", order.Customer.ID)
+	}
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
 	}
-	return s.orderRepo.Create(order)
+	createdOrder, err := s.orderRepo.Create(order)
+	if err != nil || s.fulfillment == nil {
+		return s.refs.Order(createdOrder), err
+	}
+	placedOrder, err := s.fulfillment.Place(createdOrder, customer.Address, time.Now())
+	if err != nil {
+		s.orderRepo.Delete(createdOrder.ID)
+		return models.Order{}, err
+	}
+	return s.refs.Order(placedOrder), nil
 }
 
 func (s *OrderService) GetOrder(id int) (models.Order, error) {
-	return s.orderRepo.Get(id)
+	order, err := s.orderRepo.Get(id)
+	return s.refs.Order(order), err
 }
 
+// UpdateOrder updates an order, the items of a book already ordered keep their snapshot and
+// their backorder state, the items of another book snapshot its current title and price. The
+// status, the allocations and the shipments belong to the fulfillment: changed items of an
+// order without allocated stock are placed again, the items of an allocated order are not changed.
 func (s *OrderService) UpdateOrder(order models.Order) (models.Order, error) {
-	return s.orderRepo.Update(order)
+	defer integrity.Reference()()
+
+	existing, err := s.orderRepo.Get(order.ID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	customer, err := s.customerRepo.Get(order.Customer.ID)
+	if err != nil {
+		return models.Order{}, repositories.Invalid("unknown_reference", "customer %d does not exist", order.Customer.ID)
+	}
+	order.Status = existing.Status
+	order.Allocations = existing.Allocations
+	order.Shipments = existing.Shipments
+	order.ExpectedShipAt = existing.ExpectedShipAt
+	order.Items = slices.Clone(order.Items)
+	for i, item := range order.Items {
+		order.Items[i].ID = i + 1
+		if j := slices.IndexFunc(existing.Items, func(ordered models.OrderItem) bool {
+			return ordered.Book.ID == item.Book.ID
+		}); j >= 0 {
+			order.Items[i].Title = existing.Items[j].Title
+			order.Items[i].UnitPrice = existing.Items[j].UnitPrice
+			order.Items[i].Pending = existing.Items[j].Pending
+			order.Items[i].Status = existing.Items[j].Status
+			order.Items[i].ExpectedShipAt = existing.Items[j].ExpectedShipAt
+			continue
+		}
+		if err := s.snapshot(&order.Items[i]); err != nil {
+			return models.Order{}, err
+		}
+		order.Items[i].Pending = 0
+		order.Items[i].Status = ""
+		order.Items[i].ExpectedShipAt = time.Time{}
+	}
+	order.TotalPrice = orderTotal(order.Items)
+	if s.fulfillment == nil || !itemsChanged(existing.Items, order.Items) {
+		updatedOrder, err := s.orderRepo.Update(order)
+		return s.refs.Order(updatedOrder), err
+	}
+	if len(existing.Allocations) > 0 {
+		return models.Order{}, repositories.Conflict("order_allocated", "order %d has allocated stock, items are added with POST /orders/%d/items", order.ID, order.ID)
+	}
+	// nothing was allocated yet, the changed items are placed like the items of a new order
+	placedOrder, err := s.fulfillment.Place(order, customer.Address, time.Now())
+	return s.refs.Order(placedOrder), err
 }
 
 func (s *OrderService) DeleteOrder(id int) error {
-	return s.orderRepo.Delete(id)
+	return integrity.Delete("orders", id, func() error {
+		return s.orderRepo.Delete(id)
+	})
 }
 
 func (s *OrderService) SearchOrders(query models.SearchCriteria) ([]models.Order, error) {
-	return s.orderRepo.Search(query)
+	orders, err := s.orderRepo.Search(query)
+	return s.refs.Orders(orders), err
+}
+
+// CustomerOrders searches the orders of a customer
+func (s *OrderService) CustomerOrders(customerID int, query models.SearchCriteria) ([]models.Order, error) {
+	if _, err := s.customerRepo.Get(customerID); err != nil {
+		return nil, err
+	}
+	filters := maps.Clone(query.Filters)
+	filters["customer_id"] = customerID
+	return s.SearchOrders(models.SearchCriteria{Filters: filters})
+}
+
+// OrderItems returns the items of an order, optionally of a book (book_id) or in a status
+func (s *OrderService) OrderItems(orderID int, query models.SearchCriteria) ([]models.OrderItem, error) {
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	bookID := 0
+	switch value := query.Filters["book_id"].(type) {
+	case float64:
+		bookID = int(value)
+	case int:
+		bookID = value
+	}
+	items := []models.OrderItem{}
+	for _, item := range order.Items {
+		if bookID != 0 && item.Book.ID != bookID {
+			continue
+		}
+		if status, exists := query.Filters["status"]; exists && item.Status != status {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// AddOrderItem adds an item to an order that has not shipped, it snapshots the title and the
+// price of its book and is allocated to the stock like the items of a new order
+func (s *OrderService) AddOrderItem(orderID int, item models.OrderItem) (models.OrderItem, error) {
+	defer integrity.Reference()()
+
+	order, err := s.orderRepo.Get(orderID)
+	if err != nil {
+		return models.OrderItem{}, err
+	}
+	if order.Status == models.OrderShipped || order.Status == models.OrderDelivered {
+		return models.OrderItem{}, repositories.Conflict("order_closed", "order %d is %s, items cannot be added", orderID, order.Status)
+	}
+	if slices.ContainsFunc(order.Items, func(ordered models.OrderItem) bool {
+		return ordered.Book.ID == item.Book.ID
+	}) {
+		return models.OrderItem{}, repositories.Conflict("duplicate_item", "order %d already has an item of book %d", orderID, item.Book.ID)
+	}
+
+	if err := s.snapshot(&item); err != nil {
+		return models.OrderItem{}, err
+	}
+	item.ID = 1
+	for _, ordered := range order.Items {
+		item.ID = max(item.ID, ordered.ID+1)
+	}
+
+	order.Items = append(slices.Clone(order.Items), item)
+	order.TotalPrice = orderTotal(order.Items)
+	var updatedOrder models.Order
+	if s.fulfillment == nil {
+		updatedOrder, err = s.orderRepo.Update(order)
+	} else {
+		customer, _ := s.customerRepo.Get(order.Customer.ID)
+		updatedOrder, err = s.fulfillment.PlaceItem(order, customer.Address, time.Now())
+	}
+	if err != nil {
+		return models.OrderItem{}, err
+	}
+	updatedOrder = s.refs.Order(updatedOrder)
+	return updatedOrder.Items[len(updatedOrder.Items)-1], nil
+}
+
+// snapshot copies the title and the current price of the book of an item
+func (s *OrderService) snapshot(item *models.OrderItem) error {
+	book, err := s.bookRepo.Get(item.Book.ID)
+	if err != nil {
+		return repositories.Invalid("unknown_reference", "book %d does not exist", item.Book.ID)
+	}
+	item.Title = book.Title
+	item.UnitPrice = book.Price
+	return nil
+}
+
+// itemsChanged reports whether the books or the quantities of the items of an order changed
+func itemsChanged(existing, items []models.OrderItem) bool {
+	return !slices.EqualFunc(existing, items, func(ordered, item models.OrderItem) bool {
+		return ordered.Book.ID == item.Book.ID && ordered.Quantity == item.Quantity
+	})
+}
+
+// orderTotal sums the items at their snapshot price
+func orderTotal(items []models.OrderItem) float64 {
+	total := 0.0
+	for _, item := range items {
+		total += float64(item.Quantity) * item.UnitPrice
+	}
+	return total
 }
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"bookstore.com/integrity"
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+// PurchaseOrderService manages the purchase orders sent to the suppliers,
+// received quantities enter the stock through the stock ledger
+type PurchaseOrderService struct {
+	mu           sync.Mutex
+	orderRepo    repositories.PurchaseOrderStore
+	supplierRepo repositories.SupplierStore
+	bookRepo     repositories.BookStore
+	inventory    *InventoryService
+	refs         *References
+}
+
+func NewPurchaseOrderService(orderRepo repositories.PurchaseOrderStore, supplierRepo repositories.SupplierStore, bookRepo repositories.BookStore, inventory *InventoryService, refs *References) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		orderRepo:    orderRepo,
+		supplierRepo: supplierRepo,
+		bookRepo:     bookRepo,
+		inventory:    inventory,
+		refs:         refs,
+	}
+}
+
+// CreatePurchaseOrder creates a draft purchase order
+func (s *PurchaseOrderService) CreatePurchaseOrder(order models.PurchaseOrder) (models.PurchaseOrder, error) {
+	defer integrity.Reference()()
+
+	if err := s.prepare(&order); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	order.Status = models.PurchaseOrderDraft
+	order.CreatedAt = time.Now()
+	order.SentAt = time.Time{}
+	order.ReceivedAt = time.Time{}
+	createdOrder, err := s.orderRepo.Create(order)
+	return s.refs.PurchaseOrder(createdOrder), err
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrder(id int) (models.PurchaseOrder, error) {
+	order, err := s.orderRepo.Get(id)
+	return s.refs.PurchaseOrder(order), err
+}
+
+// UpdatePurchaseOrder replaces the supplier and the lines of a draft purchase order
+func (s *PurchaseOrderService) UpdatePurchaseOrder(order models.PurchaseOrder) (models.PurchaseOrder, error) {
+	defer integrity.Reference()()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.orderRepo.Get(order.ID)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	if existing.Status != models.PurchaseOrderDraft {
+		return models.PurchaseOrder{}, repositories.Conflict("purchase_order_not_draft", "only draft purchase orders can be modified")
+	}
+	if err := s.prepare(&order); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	order.Status = existing.Status
+	order.CreatedAt = existing.CreatedAt
+	order.SentAt = time.Time{}
+	order.ReceivedAt = time.Time{}
+	updatedOrder, err := s.orderRepo.Update(order)
+	return s.refs.PurchaseOrder(updatedOrder), err
+}
+
+// DeletePurchaseOrder deletes a purchase order nothing was received for
+func (s *PurchaseOrderService) DeletePurchaseOrder(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.orderRepo.Get(id)
+	if err != nil {
+		return err
+	}
+	if existing.Status != models.PurchaseOrderDraft && existing.Status != models.PurchaseOrderSent {
+		return repositories.Conflict("purchase_order_received", "received purchase orders cannot be deleted")
+	}
+	return s.orderRepo.Delete(id)
+}
+
+func (s *PurchaseOrderService) SearchPurchaseOrders(query models.SearchCriteria) ([]models.PurchaseOrder, error) {
+	orders, err := s.orderRepo.Search(query)
+	return s.refs.PurchaseOrders(orders), err
+}
+
+// SendPurchaseOrder marks a draft purchase order as sent to its supplier
+func (s *PurchaseOrderService) SendPurchaseOrder(id int) (models.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderRepo.Get(id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	if order.Status != models.PurchaseOrderDraft {
+		return models.PurchaseOrder{}, repositories.Conflict("purchase_order_not_draft", "only draft purchase orders can be sent")
+	}
+	order.Status = models.PurchaseOrderSent
+	order.SentAt = time.Now()
+	updatedOrder, err := s.orderRepo.Update(order)
+	return s.refs.PurchaseOrder(updatedOrder), err
+}
+
+// ReceivePurchaseOrder records a delivery, each received quantity is a receipt in the stock ledger
+// at the unit cost of its line
+func (s *PurchaseOrderService) ReceivePurchaseOrder(id int, receipts []models.PurchaseOrderReceipt, user string) (models.PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderRepo.Get(id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	if order.Status != models.PurchaseOrderSent && order.Status != models.PurchaseOrderPartiallyReceived {
+		return models.PurchaseOrder{}, repositories.Conflict("purchase_order_not_sent", "only sent purchase orders can be received")
+	}
+	if len(receipts) == 0 {
+		return models.PurchaseOrder{}, repositories.Invalid("nothing_to_receive", "nothing to receive")
+	}
+
+	// the received quantities are counted on a copy of the lines, the stored order shares them,
+	// so a rejected receipt leaves the order as it was
+	order.Lines = slices.Clone(order.Lines)
+	lines := make(map[int]int)
+	for i, line := range order.Lines {
+		lines[line.ID] = i
+	}
+	for _, receipt := range receipts {
+		i, exists := lines[receipt.LineID]
+		if !exists {
+			return models.PurchaseOrder{}, repositories.Invalid("unknown_line", "line %d not found", receipt.LineID)
+		}
+		if receipt.Quantity <= 0 {
+			return models.PurchaseOrder{}, repositories.Invalid("invalid_quantity", "received quantity must be positive")
+		}
+		if order.Lines[i].Received+receipt.Quantity > order.Lines[i].Quantity {
+			return models.PurchaseOrder{}, repositories.Conflict("over_receipt", "line %d: %d ordered, %d already received", receipt.LineID, order.Lines[i].Quantity, order.Lines[i].Received)
+		}
+		order.Lines[i].Received += receipt.Quantity
+	}
+	receptionStatus(&order)
+	updatedOrder, err := s.orderRepo.Update(order)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	// the delivery is recorded before the stock, the receipts a movement failed for are then
+	// taken back from the order
+	for k, receipt := range receipts {
+		line := order.Lines[lines[receipt.LineID]]
+		if _, err := s.inventory.RecordMovement(models.StockMovement{
+			BookID:   line.Book.ID,
+			Type:     models.MovementReceipt,
+			Quantity: receipt.Quantity,
+			UnitCost: line.UnitCost,
+			Reason:   fmt.Sprintf("purchase order %d", order.ID),
+			User:     user,
+		}); err != nil {
+			order = updatedOrder
+			order.Lines = slices.Clone(order.Lines)
+			for _, unrecorded := range receipts[k:] {
+				order.Lines[lines[unrecorded.LineID]].Received -= unrecorded.Quantity
+			}
+			receptionStatus(&order)
+			s.orderRepo.Update(order)
+			return models.PurchaseOrder{}, err
+		}
+	}
+	return s.refs.PurchaseOrder(updatedOrder), nil
+}
+
+// receptionStatus sets the status of a sent purchase order from its received quantities
+func receptionStatus(order *models.PurchaseOrder) {
+	received, complete := false, true
+	for _, line := range order.Lines {
+		received = received || line.Received > 0
+		complete = complete && line.Received >= line.Quantity
+	}
+	switch {
+	case complete:
+		order.Status = models.PurchaseOrderReceived
+		if order.ReceivedAt.IsZero() {
+			order.ReceivedAt = time.Now()
+		}
+		return
+	case received:
+		order.Status = models.PurchaseOrderPartiallyReceived
+	default:
+		order.Status = models.PurchaseOrderSent
+	}
+	order.ReceivedAt = time.Time{}
+}
+
+// SupplierCosts returns the average and last unit cost of every book received from a supplier
+func (s *PurchaseOrderService) SupplierCosts(supplierID int) ([]models.SupplierCost, error) {
+	if _, err := s.supplierRepo.Get(supplierID); err != nil {
+		return nil, err
+	}
+	orders, err := s.orderRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"supplier_id": supplierID}})
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make(map[int]*models.SupplierCost)
+	for _, order := range orders {
+		for _, line := range order.Lines {
+			if line.Received == 0 {
+				continue
+			}
+			cost, exists := costs[line.Book.ID]
+			if !exists {
+				cost = &models.SupplierCost{SupplierID: supplierID, BookID: line.Book.ID}
+				if book, err := s.bookRepo.Get(line.Book.ID); err == nil {
+					cost.Title = book.Title
+				}
+				costs[line.Book.ID] = cost
+			}
+			total := cost.AverageUnitCost*float64(cost.QuantityReceived) + line.UnitCost*float64(line.Received)
+			cost.QuantityReceived += line.Received
+			cost.AverageUnitCost = total / float64(cost.QuantityReceived)
+			cost.LastUnitCost = line.UnitCost
+		}
+	}
+
+	results := make([]models.SupplierCost, 0, len(costs))
+	for _, cost := range costs {
+		results = append(results, *cost)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].BookID < results[j].BookID
+	})
+	return results, nil
+}
+
+// Margins compares the price of every received book with its average cost across the suppliers
+func (s *PurchaseOrderService) Margins() ([]models.BookMargin, error) {
+	orders, err := s.orderRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int]int)
+	totals := make(map[int]float64)
+	for _, order := range orders {
+		for _, line := range order.Lines {
+			quantities[line.Book.ID] += line.Received
+			totals[line.Book.ID] += line.UnitCost * float64(line.Received)
+		}
+	}
+
+	margins := make([]models.BookMargin, 0)
+	for bookID, quantity := range quantities {
+		if quantity == 0 {
+			continue
+		}
+		book, err := s.bookRepo.Get(bookID)
+		if err != nil {
+			continue
+		}
+		margin := models.BookMargin{
+			BookID:   book.ID,
+			Title:    book.Title,
+			Price:    book.Price,
+			UnitCost: totals[bookID] / float64(quantity),
+		}
+		margin.Margin = margin.Price - margin.UnitCost
+		if margin.Price != 0 {
+			margin.MarginPercent = margin.Margin / margin.Price * 100
+		}
+		margins = append(margins, margin)
+	}
+	sort.Slice(margins, func(i, j int) bool {
+		return margins[i].BookID < margins[j].BookID
+	})
+	return margins, nil
+}
+
+// prepare checks the supplier and the books of a purchase order, numbers its lines and computes its total
+func (s *PurchaseOrderService) prepare(order *models.PurchaseOrder) error {
+	if _, err := s.supplierRepo.Get(order.SupplierID); err != nil {
+		return repositories.Invalid("unknown_reference", "supplier %d does not exist", order.SupplierID)
+	}
+	if len(order.Lines) == 0 {
+		return repositories.Invalid("missing_lines", "a purchase order needs at least one line")
+	}
+
+	order.TotalCost = 0
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		book, err := s.bookRepo.Get(line.Book.ID)
+		if err != nil {
+			return repositories.Invalid("unknown_reference", "line %d: book %d does not exist", i+1, line.Book.ID)
+		}
+		if line.Quantity <= 0 {
+			return repositories.Invalid("invalid_quantity", "line %d: quantity must be positive", i+1)
+		}
+		if line.UnitCost < 0 {
+			return repositories.Invalid("invalid_unit_cost", "line %d: unit cost must not be negative", i+1)
+		}
+		line.ID = i + 1
+		line.Book = book
+		line.Received = 0
+		order.TotalCost += line.UnitCost * float64(line.Quantity)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"slices"
+	"strings"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+// References hydrates the entities the stores reference by id: the author of a book, the
+// customer and the books of an order, the books of a purchase order, the book of a sale. Each
+// read returns their current details, a reference to a missing entity is left as its id. The
+// nil References hydrates nothing.
+type References struct {
+	authors   repositories.AuthorStore
+	books     repositories.BookStore
+	customers repositories.CustomerStore
+}
+
+func NewReferences(authors repositories.AuthorStore, books repositories.BookStore, customers repositories.CustomerStore) *References {
+	return &References{authors: authors, books: books, customers: customers}
+}
+
+// Book returns the book with its author
+func (r *References) Book(book models.Book) models.Book {
+	if r == nil {
+		return book
+	}
+	if author, err := r.authors.Get(book.Author.ID); err == nil {
+		book.Author = author
+	}
+	return book
+}
+
+func (r *References) Books(books []models.Book) []models.Book {
+	for i := range books {
+		books[i] = r.Book(books[i])
+	}
+	return books
+}
+
+// Order returns the order with its customer and the books of its items, the items keep the
+// title and the price snapshot of the books when they were ordered
+func (r *References) Order(order models.Order) models.Order {
+	if r == nil {
+		return order
+	}
+	if customer, err := r.customers.Get(order.Customer.ID); err == nil {
+		order.Customer = customer
+	}
+	// the items are copied, the stored order shares them
+	order.Items = slices.Clone(order.Items)
+	for i := range order.Items {
+		order.Items[i].Book = r.bookByID(order.Items[i].Book)
+	}
+	return order
+}
+
+func (r *References) Orders(orders []models.Order) []models.Order {
+	for i := range orders {
+		orders[i] = r.Order(orders[i])
+	}
+	return orders
+}
+
+// PurchaseOrder returns the purchase order with the books of its lines
+func (r *References) PurchaseOrder(order models.PurchaseOrder) models.PurchaseOrder {
+	if r == nil {
+		return order
+	}
+	order.Lines = slices.Clone(order.Lines)
+	for i := range order.Lines {
+		order.Lines[i].Book = r.bookByID(order.Lines[i].Book)
+	}
+	return order
+}
+
+func (r *References) PurchaseOrders(orders []models.PurchaseOrder) []models.PurchaseOrder {
+	for i := range orders {
+		orders[i] = r.PurchaseOrder(orders[i])
+	}
+	return orders
+}
+
+// BookSale returns the sale with its book, a sale of a deleted book keeps the title of its book
+func (r *References) BookSale(sale models.BookSale) models.BookSale {
+	if r == nil {
+		return sale
+	}
+	sale.Book = r.bookByID(sale.Book)
+	return sale
+}
+
+func (r *References) BookSales(sales []models.BookSale) []models.BookSale {
+	for i := range sales {
+		sales[i] = r.BookSale(sales[i])
+	}
+	return sales
+}
+
+// AuthorIDs returns the ids of the authors whose first or last name contains name
+func (r *References) AuthorIDs(name string) ([]int, error) {
+	authors, err := r.authors.Search(models.SearchCriteria{Filters: map[string]interface{}{}})
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	for _, author := range authors {
+		if strings.Contains(author.FirstName, name) || strings.Contains(author.LastName, name) {
+			ids = append(ids, author.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *References) bookByID(reference models.Book) models.Book {
+	book, err := r.books.Get(reference.ID)
+	if err != nil {
+		return reference
+	}
+	return r.Book(book)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/notifications"
+	"bookstore.com/repositories"
+)
+
+// ReorderConfig configures how reorder quantities are computed and how often stock is scanned
+type ReorderConfig struct {
+	SalesWindow  time.Duration // window of book sales used to compute the sales velocity
+	LeadTime     time.Duration // expected delay between the reorder and the delivery
+	ScanInterval time.Duration
+}
+
+var DefaultReorderConfig = ReorderConfig{
+	SalesWindow:  30 * 24 * time.Hour,
+	LeadTime:     7 * 24 * time.Hour,
+	ScanInterval: 15 * time.Minute,
+}
+
+type ReorderService struct {
+	bookRepo repositories.BookStore
+	saleRepo repositories.BookSaleStore
+	sink     notifications.Sink
+	config   ReorderConfig
+
+	mu      sync.Mutex
+	alerted map[int]bool
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func NewReorderService(bookRepo repositories.BookStore, saleRepo repositories.BookSaleStore, sink notifications.Sink, config ReorderConfig) *ReorderService {
+	return &ReorderService{
+		bookRepo: bookRepo,
+		saleRepo: saleRepo,
+		sink:     sink,
+		config:   config,
+		alerted:  make(map[int]bool),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Suggestions lists the books at or below their reorder threshold with the quantity to reorder
+func (s *ReorderService) Suggestions(now time.Time) ([]models.ReorderSuggestion, error) {
+	books, err := s.bookRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return nil, err
+	}
+	sales, err := s.saleRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return nil, err
+	}
+
+	windowStart := now.Add(-s.config.SalesWindow)
+	sold := make(map[int]int)
+	for _, sale := range sales {
+		if sale.SoldAt.Before(windowStart) || sale.SoldAt.After(now) {
+			continue
+		}
+		sold[sale.Book.ID] += sale.Quantity
+	}
+	windowDays := s.config.SalesWindow.Hours() / 24
+	leadDays := s.config.LeadTime.Hours() / 24
+
+	suggestions := make([]models.ReorderSuggestion, 0)
+	for _, book := range books {
+		if book.ReorderThreshold <= 0 || book.Stock > book.ReorderThreshold {
+			continue
+		}
+		target := book.ReorderTarget
+		if target < book.ReorderThreshold {
+			target = book.ReorderThreshold
+		}
+
+		dailySales := 0.0
+		if windowDays > 0 {
+			dailySales = float64(sold[book.ID]) / windowDays
+		}
+		quantity := target - book.Stock + int(math.Ceil(dailySales*leadDays))
+		if quantity <= 0 {
+			continue
+		}
+
+		suggestions = append(suggestions, models.ReorderSuggestion{
+			BookID:            book.ID,
+			Title:             book.Title,
+			Stock:             book.Stock,
+			ReorderThreshold:  book.ReorderThreshold,
+			ReorderTarget:     target,
+			DailySales:        dailySales,
+			SuggestedQuantity: quantity,
+			CreatedAt:         now,
+		})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].BookID < suggestions[j].BookID
+	})
+	return suggestions, nil
+}
+
+// Scan notifies the sink of the books that went below their threshold since the previous scan,
+// a book is notified again only after its stock went back above the threshold
+func (s *ReorderService) Scan(now time.Time) error {
+	suggestions, err := s.Suggestions(now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the books back above their threshold are forgotten, the new ones are only remembered once
+	// notified so a failed notification is sent again by the next scan
+	alerted := make(map[int]bool)
+	var fresh []models.ReorderSuggestion
+	for _, suggestion := range suggestions {
+		if s.alerted[suggestion.BookID] {
+			alerted[suggestion.BookID] = true
+		} else {
+			fresh = append(fresh, suggestion)
+		}
+	}
+	s.alerted = alerted
+
+	if len(fresh) == 0 {
+		return nil
+	}
+	if err := s.sink.Notify(notifications.Notification{
+		Subject: "Low stock alert",
+		Message: fmt.Sprintf("%d book(s) reached their reorder threshold", len(fresh)),
+		Data:    fresh,
+	}); err != nil {
+		return err
+	}
+	for _, suggestion := range fresh {
+		s.alerted[suggestion.BookID] = true
+	}
+	return nil
+}
+
+// Start scans the stock at the configured interval in a background goroutine
+func (s *ReorderService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.config.ScanInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.Scan(time.Now()); err != nil {
+				log.Printf("ReorderService: scan error: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *ReorderService) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+)
+
+// ReportSchedule describes when the report of a period is generated and how long it is kept.
+// The report of the period that just ended is generated at Hour:Minute on the first day
+// of the next period (every day, every Monday or the first of every month).
+type ReportSchedule struct {
+	Period    string
+	Hour      int
+	Minute    int
+	Retention time.Duration // zero keeps the reports forever
+}
+
+// DefaultReportSchedules generates daily, weekly and monthly reports shortly after midnight
+var DefaultReportSchedules = []ReportSchedule{
+	{Period: models.DailyReport, Hour: 0, Minute: 5, Retention: 90 * 24 * time.Hour},
+	{Period: models.WeeklyReport, Hour: 0, Minute: 15, Retention: 2 * 365 * 24 * time.Hour},
+	{Period: models.MonthlyReport, Hour: 0, Minute: 30},
+}
+
+type ReportScheduler struct {
+	reportService *SalesReportService
+	schedules     []ReportSchedule
+	interval      time.Duration
+	stop          chan struct{}
+	once          sync.Once
+}
+
+func NewReportScheduler(reportService *SalesReportService, schedules []ReportSchedule) *ReportScheduler {
+	return &ReportScheduler{
+		reportService: reportService,
+		schedules:     schedules,
+		interval:      time.Minute,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Start checks the schedules every minute in a background goroutine
+func (s *ReportScheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		s.RunDue(time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				s.RunDue(now)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *ReportScheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// RunDue generates the reports whose scheduled time has passed and applies the retention policies
+func (s *ReportScheduler) RunDue(now time.Time) {
+	for _, schedule := range s.schedules {
+		currentFrom, _, err := PeriodBounds(schedule.Period, now)
+		if err != nil {
+			log.Printf("ReportScheduler: %v", err)
+			continue
+		}
+
+		runAt := currentFrom.Add(time.Duration(schedule.Hour)*time.Hour + time.Duration(schedule.Minute)*time.Minute)
+		if now.Before(runAt) {
+			continue
+		}
+
+		previousFrom, _, _ := PeriodBounds(schedule.Period, currentFrom.Add(-time.Nanosecond))
+		_, found, err := s.reportService.FindReport(schedule.Period, previousFrom)
+		if err != nil {
+			log.Printf("ReportScheduler: %s report lookup error: %v", schedule.Period, err)
+			continue
+		}
+		if !found {
+			report, err := s.reportService.CreateReport(schedule.Period, previousFrom)
+			if err != nil {
+				log.Printf("ReportScheduler: %s report generation error: %v", schedule.Period, err)
+				continue
+			}
+			log.Printf("ReportScheduler: generated %s report %d for %s", schedule.Period, report.ID, previousFrom.Format("2006-01-02"))
+		}
+
+		if schedule.Retention > 0 {
+			purged, err := s.reportService.PurgeReports(schedule.Period, now.Add(-schedule.Retention))
+			if err != nil {
+				log.Printf("ReportScheduler: %s retention error: %v", schedule.Period, err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("ReportScheduler: purged %d %s reports", purged, schedule.Period)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type SalesReportService struct {
+	reportRepo repositories.SalesReportStore
+	orderRepo  repositories.OrderStore
+	refs       *References
+}
+
+func NewSalesReportService(reportRepo repositories.SalesReportStore, orderRepo repositories.OrderStore, refs *References) *SalesReportService {
+	return &SalesReportService{reportRepo: reportRepo, orderRepo: orderRepo, refs: refs}
+}
+
+// PeriodBounds returns the [from, to) window of the daily, weekly or monthly period containing t.
+// Weeks start on Monday.
+func PeriodBounds(period string, t time.Time) (time.Time, time.Time, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case models.DailyReport:
+		return day, day.AddDate(0, 0, 1), nil
+	case models.WeeklyReport:
+		from := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return from, from.AddDate(0, 0, 7), nil
+	case models.MonthlyReport:
+		from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, repositories.Invalid("invalid_period", "unknown report period: %s", period)
+	}
+}
+
+// GenerateReport aggregates the orders created in [from, to) without storing the result. The
+// revenue counts the items at the price they were ordered, the top selling books are the
+// current books with the title and the price of their latest order in the period.
+func (s *SalesReportService) GenerateReport(period string, from, to time.Time) (models.SalesReport, error) {
+	orders, err := s.orderRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+
+	report := models.SalesReport{
+		Period:          period,
+		From:            from,
+		To:              to,
+		Timestamp:       time.Now(),
+		TopSellingBooks: make([]models.BookSale, 0),
+	}
+	bookSalesMap := make(map[int]*models.BookSale)
+
+	for _, order := range orders {
+		if order.CreatedAt.Before(from) || !order.CreatedAt.Before(to) {
+			continue
+		}
+		report.TotalOrders++
+
+		orderRevenue := 0.0
+		for _, item := range order.Items {
+			orderRevenue += float64(item.Quantity) * item.UnitPrice
+			if existingSale, exists := bookSalesMap[item.Book.ID]; exists {
+				existingSale.Quantity += item.Quantity
+				if order.CreatedAt.After(existingSale.SoldAt) {
+					existingSale.Book.Title = item.Title
+					existingSale.Book.Price = item.UnitPrice
+					existingSale.SoldAt = order.CreatedAt
+				}
+			} else {
+				book := models.Book{ID: item.Book.ID, Title: item.Title, Price: item.UnitPrice}
+				bookSalesMap[item.Book.ID] = &models.BookSale{Book: book, Quantity: item.Quantity, SoldAt: order.CreatedAt}
+			}
+		}
+		report.TotalRevenue += orderRevenue
+	}
+
+	for _, sale := range bookSalesMap {
+		sale.Book = s.soldBook(sale.Book)
+		report.TopSellingBooks = append(report.TopSellingBooks, *sale)
+	}
+	sort.Slice(report.TopSellingBooks, func(i, j int) bool {
+		return report.TopSellingBooks[i].Quantity > report.TopSellingBooks[j].Quantity
+	})
+
+	return report, nil
+}
+
+// soldBook returns the current details of a sold book with the title and the price it was sold at
+func (s *SalesReportService) soldBook(sold models.Book) models.Book {
+	if s.refs == nil {
+		return sold
+	}
+	book := s.refs.bookByID(sold)
+	book.Title = sold.Title
+	book.Price = sold.Price
+	return book
+}
+
+// CreateReport generates the report of the period containing at and stores it in the report history
+func (s *SalesReportService) CreateReport(period string, at time.Time) (models.SalesReport, error) {
+	from, to, err := PeriodBounds(period, at)
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+	report, err := s.GenerateReport(period, from, to)
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+	return s.reportRepo.Create(report)
+}
+
+func (s *SalesReportService) GetReport(id int) (models.SalesReport, error) {
+	return s.reportRepo.Get(id)
+}
+
+func (s *SalesReportService) DeleteReport(id int) error {
+	return s.reportRepo.Delete(id)
+}
+
+func (s *SalesReportService) SearchReports(query models.SearchCriteria) ([]models.SalesReport, error) {
+	return s.reportRepo.Search(query)
+}
+
+// FindReport returns the stored report of a period starting at from, if any
+func (s *SalesReportService) FindReport(period string, from time.Time) (models.SalesReport, bool, error) {
+	reports, err := s.reportRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{
+		"period": period,
+		"from":   from,
+	}})
+	if err != nil {
+		return models.SalesReport{}, false, err
+	}
+	for _, report := range reports {
+		if report.From.Equal(from) {
+			return report, true, nil
+		}
+	}
+	return models.SalesReport{}, false, nil
+}
+
+// CompareReport compares a stored report with the previous period. The stored report of the
+// previous period is used when it exists, otherwise it is generated from the orders.
+func (s *SalesReportService) CompareReport(id int) (models.SalesReportComparison, error) {
+	current, err := s.reportRepo.Get(id)
+	if err != nil {
+		return models.SalesReportComparison{}, err
+	}
+
+	previousFrom, previousTo, err := PeriodBounds(current.Period, current.From.Add(-time.Nanosecond))
+	if err != nil {
+		return models.SalesReportComparison{}, err
+	}
+	previous, found, err := s.FindReport(current.Period, previousFrom)
+	if err != nil {
+		return models.SalesReportComparison{}, err
+	}
+	if !found {
+		previous, err = s.GenerateReport(current.Period, previousFrom, previousTo)
+		if err != nil {
+			return models.SalesReportComparison{}, err
+		}
+	}
+
+	return models.SalesReportComparison{
+		Current:              current,
+		Previous:             previous,
+		RevenueChange:        current.TotalRevenue - previous.TotalRevenue,
+		RevenueChangePercent: percentChange(previous.TotalRevenue, current.TotalRevenue),
+		OrdersChange:         current.TotalOrders - previous.TotalOrders,
+		OrdersChangePercent:  percentChange(float64(previous.TotalOrders), float64(current.TotalOrders)),
+	}, nil
+}
+
+// PurgeReports deletes the reports of a period that ended before the given time
+func (s *SalesReportService) PurgeReports(period string, before time.Time) (int, error) {
+	reports, err := s.reportRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"period": period}})
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, report := range reports {
+		if !report.To.Before(before) {
+			continue
+		}
+		if err := s.reportRepo.Delete(report.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func percentChange(previous, current float64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return (current - previous) / previous * 100
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"bookstore.com/integrity"
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+	"bookstore.com/shipping"
+)
+
+// ShipmentService ships the allocated items of the orders with the carriers and follows their
+// tracking, the orders move to shipped then delivered as the tracking events arrive
+type ShipmentService struct {
+	shipmentRepo  repositories.ShipmentStore
+	orderRepo     repositories.OrderStore
+	warehouseRepo repositories.WarehouseStore
+	customerRepo  repositories.CustomerStore
+	carriers      map[string]shipping.CarrierAdapter
+	defaultName   string
+	pollInterval  time.Duration
+
+	mu   sync.Mutex
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewShipmentService creates the shipment service, shipments use the first carrier unless
+// they name another one and the tracking of the carriers is polled every pollInterval
+func NewShipmentService(shipmentRepo repositories.ShipmentStore, orderRepo repositories.OrderStore, warehouseRepo repositories.WarehouseStore, customerRepo repositories.CustomerStore, pollInterval time.Duration, carriers ...shipping.CarrierAdapter) *ShipmentService {
+	s := &ShipmentService{
+		shipmentRepo:  shipmentRepo,
+		orderRepo:     orderRepo,
+		warehouseRepo: warehouseRepo,
+		customerRepo:  customerRepo,
+		carriers:      make(map[string]shipping.CarrierAdapter),
+		pollInterval:  pollInterval,
+		stop:          make(chan struct{}),
+	}
+	for _, carrier := range carriers {
+		if s.defaultName == "" {
+			s.defaultName = carrier.Name()
+		}
+		s.carriers[carrier.Name()] = carrier
+	}
+	return s
+}
+
+// CreateShipment ships allocated items of an order from a warehouse. Without packages a single
+// package holds every item of the warehouse not shipped yet.
+func (s *ShipmentService) CreateShipment(orderID int, shipment models.Shipment) (models.Shipment, error) {
+	defer integrity.Reference()()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderRepo.Get(orderID)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	if shipment.Carrier == "" {
+		shipment.Carrier = s.defaultName
+	}
+	carrier, exists := s.carriers[shipment.Carrier]
+	if !exists {
+		return models.Shipment{}, repositories.Invalid("unknown_carrier", "unknown carrier: %s", shipment.Carrier)
+	}
+
+	remaining, err := s.unshipped(order, shipment.WarehouseID)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	if len(shipment.Packages) == 0 {
+		var items []models.AllocationItem
+		for bookID, quantity := range remaining {
+			if quantity > 0 {
+				items = append(items, models.AllocationItem{BookID: bookID, Quantity: quantity})
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].BookID < items[j].BookID
+		})
+		if len(items) == 0 {
+			return models.Shipment{}, repositories.Conflict("nothing_to_ship", "nothing left to ship from warehouse %d", shipment.WarehouseID)
+		}
+		shipment.Packages = []models.Package{{Items: items}}
+	}
+
+	shipment.Items = nil
+	positions := make(map[int]int)
+	for _, pkg := range shipment.Packages {
+		if len(pkg.Items) == 0 {
+			return models.Shipment{}, repositories.Invalid("empty_package", "a package needs at least one item")
+		}
+		for _, item := range pkg.Items {
+			if item.Quantity <= 0 {
+				return models.Shipment{}, repositories.Invalid("invalid_quantity", "shipped quantity must be positive")
+			}
+			if i, exists := positions[item.BookID]; exists {
+				shipment.Items[i].Quantity += item.Quantity
+				continue
+			}
+			positions[item.BookID] = len(shipment.Items)
+			shipment.Items = append(shipment.Items, item)
+		}
+	}
+	for _, item := range shipment.Items {
+		if item.Quantity > remaining[item.BookID] {
+			return models.Shipment{}, repositories.Conflict("over_shipment", "book %d: only %d allocated to warehouse %d left to ship", item.BookID, remaining[item.BookID], shipment.WarehouseID)
+		}
+	}
+
+	var from models.Address
+	if shipment.WarehouseID != 0 {
+		warehouse, err := s.warehouseRepo.Get(shipment.WarehouseID)
+		if err != nil {
+			return models.Shipment{}, repositories.Invalid("unknown_reference", "warehouse %d does not exist", shipment.WarehouseID)
+		}
+		from = warehouse.Address
+	}
+	customer, err := s.customerRepo.Get(order.Customer.ID)
+	if err != nil {
+		return models.Shipment{}, repositories.Invalid("unknown_reference", "customer %d does not exist", order.Customer.ID)
+	}
+	to := customer.Address
+
+	shipment.OrderID = order.ID
+	label, err := carrier.CreateLabel(shipment, from, to)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	shipment.TrackingNumber = label.TrackingNumber
+	shipment.LabelURL = label.LabelURL
+	shipment.Status = models.ShipmentLabelCreated
+	shipment.Events = nil
+	shipment.CreatedAt = time.Now()
+	shipment.ShippedAt = time.Time{}
+	shipment.DeliveredAt = time.Time{}
+
+	createdShipment, err := s.shipmentRepo.Create(shipment)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	return createdShipment, s.refreshOrder(order.ID)
+}
+
+func (s *ShipmentService) GetShipment(id int) (models.Shipment, error) {
+	return s.shipmentRepo.Get(id)
+}
+
+func (s *ShipmentService) SearchShipments(query models.SearchCriteria) ([]models.Shipment, error) {
+	return s.shipmentRepo.Search(query)
+}
+
+// OrderShipments returns the shipments of an order, of the given customer unless customerID is 0
+func (s *ShipmentService) OrderShipments(orderID, customerID int) ([]models.Shipment, error) {
+	order, err := s.orderRepo.Get(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if customerID != 0 && order.Customer.ID != customerID {
+		return nil, ErrForbidden
+	}
+	return s.shipmentRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"order_id": orderID}})
+}
+
+// Track polls the carrier of a shipment and applies its new tracking events
+func (s *ShipmentService) Track(id int) (models.Shipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shipment, err := s.shipmentRepo.Get(id)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	return s.track(shipment)
+}
+
+// Poll tracks every shipment not delivered yet
+func (s *ShipmentService) Poll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shipments, err := s.shipmentRepo.Search(models.SearchCriteria{Filters: make(map[string]interface{})})
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, shipment := range shipments {
+		if shipment.Status == models.ShipmentDelivered {
+			continue
+		}
+		if _, err := s.track(shipment); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("shipment %d: %w", shipment.ID, err)
+		}
+	}
+	return firstErr
+}
+
+// Start polls the tracking of the carriers at the configured interval in a background goroutine
+func (s *ShipmentService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Poll(); err != nil {
+					log.Printf("ShipmentService: poll error: %v", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *ShipmentService) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *ShipmentService) track(shipment models.Shipment) (models.Shipment, error) {
+	if shipment.Status == models.ShipmentDelivered {
+		return shipment, nil
+	}
+	carrier, exists := s.carriers[shipment.Carrier]
+	if !exists {
+		return models.Shipment{}, repositories.Invalid("unknown_carrier", "unknown carrier: %s", shipment.Carrier)
+	}
+	events, err := carrier.Track(shipment.TrackingNumber)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	if len(events) == len(shipment.Events) {
+		return shipment, nil
+	}
+
+	shipment.Events = events
+	for _, event := range events {
+		switch event.Status {
+		case models.ShipmentInTransit, models.ShipmentOutForDelivery, models.ShipmentDelivered:
+			if shipment.ShippedAt.IsZero() {
+				shipment.ShippedAt = event.OccurredAt
+			}
+		}
+		if event.Status == models.ShipmentDelivered {
+			shipment.DeliveredAt = event.OccurredAt
+		}
+	}
+	if len(events) > 0 {
+		shipment.Status = events[len(events)-1].Status
+	}
+
+	updatedShipment, err := s.shipmentRepo.Update(shipment)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	return updatedShipment, s.refreshOrder(shipment.OrderID)
+}
+
+// unshipped returns the quantity per book allocated to a warehouse and not shipped yet
+func (s *ShipmentService) unshipped(order models.Order, warehouseID int) (map[int]int, error) {
+	remaining := make(map[int]int)
+	for _, allocation := range order.Allocations {
+		if allocation.WarehouseID != warehouseID {
+			continue
+		}
+		for _, item := range allocation.Items {
+			remaining[item.BookID] += item.Quantity
+		}
+	}
+	shipments, err := s.shipmentRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"order_id": order.ID}})
+	if err != nil {
+		return nil, err
+	}
+	for _, shipment := range shipments {
+		if shipment.WarehouseID != warehouseID {
+			continue
+		}
+		for _, item := range shipment.Items {
+			remaining[item.BookID] -= item.Quantity
+		}
+	}
+	return remaining, nil
+}
+
+// refreshOrder copies the shipments into their order. An order with every item shipped moves
+// to shipped once all its parcels left the warehouses, then to delivered once all are delivered.
+func (s *ShipmentService) refreshOrder(orderID int) error {
+	for {
+		err := s.copyShipments(orderID)
+		if !errors.Is(err, repositories.ErrVersionConflict) {
+			return err
+		}
+		// the order changed since it was read, the shipments are copied into its latest version
+	}
+}
+
+func (s *ShipmentService) copyShipments(orderID int) error {
+	order, err := s.orderRepo.Get(orderID)
+	if err != nil {
+		return err
+	}
+	shipments, err := s.shipmentRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"order_id": orderID}})
+	if err != nil {
+		return err
+	}
+	order.Shipments = shipments
+
+	ordered, shipped := 0, 0
+	for _, item := range order.Items {
+		ordered += item.Quantity
+	}
+	departed, delivered := len(shipments) > 0, len(shipments) > 0
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			shipped += item.Quantity
+		}
+		if shipment.ShippedAt.IsZero() {
+			departed = false
+		}
+		if shipment.Status != models.ShipmentDelivered {
+			delivered = false
+		}
+	}
+	if shipped >= ordered && pendingQuantity(order) == 0 {
+		switch {
+		case delivered:
+			order.Status = models.OrderDelivered
+		case departed:
+			order.Status = models.OrderShipped
+		}
+	}
+
+	_, err = s.orderRepo.Update(order)
+	return err
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+	"bookstore.com/search"
+)
+
+// the kinds of suggestions
+const (
+	SuggestBook   = "book"
+	SuggestAuthor = "author"
+)
+
+// SuggestService completes the titles of the books and the names of the authors as they are
+// typed, despite typos. The entries are boosted by their popularity: the copies of a book sold,
+// and of all their books for an author. The entries are kept up to date by IndexBook and
+// IndexAuthor, the popularity by SalesChanged which counts the copies of the sale recorded or
+// deleted for its book and their author.
+type SuggestService struct {
+	bookRepo   repositories.BookStore
+	authorRepo repositories.AuthorStore
+	saleRepo   repositories.BookSaleStore
+	suggester  *search.Suggester
+
+	mu         sync.Mutex
+	sales      map[int]models.BookSale // the sales counted, to take a deleted one back
+	sold       map[int]int             // copies sold per book
+	authorOf   map[int]int             // author of every book
+	authorSold map[int]int             // copies sold per author
+}
+
+func NewSuggestService(bookRepo repositories.BookStore, authorRepo repositories.AuthorStore, saleRepo repositories.BookSaleStore) *SuggestService {
+	s := &SuggestService{
+		bookRepo:   bookRepo,
+		authorRepo: authorRepo,
+		saleRepo:   saleRepo,
+		suggester:  search.NewSuggester(),
+		sales:      make(map[int]models.BookSale),
+		sold:       make(map[int]int),
+		authorOf:   make(map[int]int),
+		authorSold: make(map[int]int),
+	}
+	all := models.SearchCriteria{Filters: map[string]interface{}{}}
+	books, err := bookRepo.Search(all)
+	if err != nil {
+		log.Printf("SuggestService: indexing books error: %v", err)
+	}
+	for _, book := range books {
+		s.suggester.Add(SuggestBook, book.ID, book.Title)
+		s.authorOf[book.ID] = book.Author.ID
+	}
+	authors, err := authorRepo.Search(all)
+	if err != nil {
+		log.Printf("SuggestService: indexing authors error: %v", err)
+	}
+	for _, author := range authors {
+		s.suggester.Add(SuggestAuthor, author.ID, authorName(author))
+	}
+	sales, err := saleRepo.Search(all)
+	if err != nil {
+		log.Printf("SuggestService: popularity error: %v", err)
+	}
+	for _, sale := range sales {
+		s.sales[sale.ID] = sale
+		s.count(sale.Book.ID, sale.Quantity)
+	}
+	return s
+}
+
+// IndexBook indexes the current title of a book, or removes it when it was deleted. The copies
+// sold of a book moving to another author, or deleted, leave the popularity of its author.
+func (s *SuggestService) IndexBook(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	book, err := s.bookRepo.Get(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		s.suggester.Remove(SuggestBook, id)
+		if authorID, exists := s.authorOf[id]; exists {
+			s.countAuthor(authorID, -s.sold[id])
+			delete(s.authorOf, id)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("SuggestService: indexing book %d error: %v", id, err)
+		return
+	}
+	s.suggester.Add(SuggestBook, book.ID, book.Title)
+	s.suggester.SetBoost(SuggestBook, book.ID, popularity(s.sold[book.ID]))
+	if authorID, exists := s.authorOf[book.ID]; !exists || authorID != book.Author.ID {
+		if exists {
+			s.countAuthor(authorID, -s.sold[book.ID])
+		}
+		s.authorOf[book.ID] = book.Author.ID
+		s.countAuthor(book.Author.ID, s.sold[book.ID])
+	}
+}
+
+// IndexAuthor indexes the current name of an author, or removes it when it was deleted
+func (s *SuggestService) IndexAuthor(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	author, err := s.authorRepo.Get(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		s.suggester.Remove(SuggestAuthor, id)
+		return
+	}
+	if err != nil {
+		log.Printf("SuggestService: indexing author %d error: %v", id, err)
+		return
+	}
+	s.suggester.Add(SuggestAuthor, author.ID, authorName(author))
+	s.suggester.SetBoost(SuggestAuthor, author.ID, popularity(s.authorSold[author.ID]))
+}
+
+// SalesChanged counts the copies of a sale recorded, or takes back those of a deleted sale
+func (s *SuggestService) SalesChanged(saleID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sale, err := s.saleRepo.Get(saleID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		if counted, exists := s.sales[saleID]; exists {
+			delete(s.sales, saleID)
+			s.count(counted.Book.ID, -counted.Quantity)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("SuggestService: popularity of sale %d error: %v", saleID, err)
+		return
+	}
+	if _, exists := s.sales[saleID]; !exists {
+		s.sales[saleID] = sale
+		s.count(sale.Book.ID, sale.Quantity)
+	}
+}
+
+// count adds copies sold to the popularity of a book and of its author
+func (s *SuggestService) count(bookID, copies int) {
+	s.sold[bookID] += copies
+	s.suggester.SetBoost(SuggestBook, bookID, popularity(s.sold[bookID]))
+	if authorID, exists := s.authorOf[bookID]; exists {
+		s.countAuthor(authorID, copies)
+	}
+}
+
+func (s *SuggestService) countAuthor(authorID, copies int) {
+	s.authorSold[authorID] += copies
+	s.suggester.SetBoost(SuggestAuthor, authorID, popularity(s.authorSold[authorID]))
+}
+
+// Suggest returns at most limit titles and names completing the query, the best first
+func (s *SuggestService) Suggest(query string, limit int) []models.Suggestion {
+	matches := s.suggester.Suggest(query, limit)
+	suggestions := make([]models.Suggestion, len(matches))
+	for i, match := range matches {
+		suggestions[i] = models.Suggestion{Kind: match.Kind, ID: match.ID, Text: match.Text, Score: match.Score}
+	}
+	return suggestions
+}
+
+// popularity adds log(1+n)/4 to the score of an entry of n copies sold, so a bestseller
+// outranks a close match but not a match with fewer typos
+func popularity(copies int) float64 {
+	return math.Log1p(float64(copies)) / 4
+}
+
+func authorName(author models.Author) string {
+	return strings.TrimSpace(author.FirstName + " " + author.LastName)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"time"
+
+	"bookstore.com/integrity"
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type SupplierService struct {
+	supplierRepo repositories.SupplierStore
+}
+
+func NewSupplierService(repo repositories.SupplierStore) *SupplierService {
+	return &SupplierService{supplierRepo: repo}
+}
+
+func (s *SupplierService) CreateSupplier(supplier models.Supplier) (models.Supplier, error) {
+	if err := validateSupplierKind(supplier.Kind); err != nil {
+		return models.Supplier{}, err
+	}
+	if supplier.CreatedAt.IsZero() {
+		supplier.CreatedAt = time.Now()
+	}
+	return s.supplierRepo.Create(supplier)
+}
+
+func (s *SupplierService) GetSupplier(id int) (models.Supplier, error) {
+	return s.supplierRepo.Get(id)
+}
+
+func (s *SupplierService) UpdateSupplier(supplier models.Supplier) (models.Supplier, error) {
+	if err := validateSupplierKind(supplier.Kind); err != nil {
+		return models.Supplier{}, err
+	}
+	return s.supplierRepo.Update(supplier)
+}
+
+func (s *SupplierService) DeleteSupplier(id int) error {
+	return integrity.Delete("suppliers", id, func() error {
+		return s.supplierRepo.Delete(id)
+	})
+}
+
+func (s *SupplierService) SearchSuppliers(query models.SearchCriteria) ([]models.Supplier, error) {
+	return s.supplierRepo.Search(query)
+}
+
+func validateSupplierKind(kind string) error {
+	if kind != models.SupplierPublisher && kind != models.SupplierDistributor {
+		return repositories.Invalid("invalid_supplier_kind", "supplier kind must be publisher or distributor")
+	}
+	return nil
+}
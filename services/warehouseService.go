@@ -0,0 +1,64 @@
+package services
+
+import (
+	"time"
+
+	"bookstore.com/integrity"
+	"bookstore.com/models"
+	"bookstore.com/repositories"
+)
+
+type WarehouseService struct {
+	warehouseRepo repositories.WarehouseStore
+	levelRepo     repositories.StockLevelStore
+	inventory     *InventoryService
+}
+
+// NewWarehouseService creates a warehouse service, the stock recorded before the first
+// warehouse is moved into it when an inventory service is given
+func NewWarehouseService(warehouseRepo repositories.WarehouseStore, levelRepo repositories.StockLevelStore, inventory *InventoryService) *WarehouseService {
+	return &WarehouseService{warehouseRepo: warehouseRepo, levelRepo: levelRepo, inventory: inventory}
+}
+
+// CreateWarehouse creates a warehouse, the stock not held by any warehouse yet moves into it
+func (s *WarehouseService) CreateWarehouse(warehouse models.Warehouse) (models.Warehouse, error) {
+	if warehouse.CreatedAt.IsZero() {
+		warehouse.CreatedAt = time.Now()
+	}
+	createdWarehouse, err := s.warehouseRepo.Create(warehouse)
+	if err != nil || s.inventory == nil {
+		return createdWarehouse, err
+	}
+	if err := s.inventory.AssignUnassigned(createdWarehouse.ID); err != nil {
+		return models.Warehouse{}, err
+	}
+	return createdWarehouse, nil
+}
+
+func (s *WarehouseService) GetWarehouse(id int) (models.Warehouse, error) {
+	return s.warehouseRepo.Get(id)
+}
+
+func (s *WarehouseService) UpdateWarehouse(warehouse models.Warehouse) (models.Warehouse, error) {
+	return s.warehouseRepo.Update(warehouse)
+}
+
+// DeleteWarehouse deletes an empty warehouse, its stock has to be transferred first
+func (s *WarehouseService) DeleteWarehouse(id int) error {
+	levels, err := s.levelRepo.Search(models.SearchCriteria{Filters: map[string]interface{}{"warehouse_id": id}})
+	if err != nil {
+		return err
+	}
+	for _, level := range levels {
+		if level.Quantity != 0 {
+			return repositories.Conflict("warehouse_not_empty", "the warehouse still holds stock")
+		}
+	}
+	return integrity.Delete("warehouses", id, func() error {
+		return s.warehouseRepo.Delete(id)
+	})
+}
+
+func (s *WarehouseService) SearchWarehouses(query models.SearchCriteria) ([]models.Warehouse, error) {
+	return s.warehouseRepo.Search(query)
+}
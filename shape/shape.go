@@ -0,0 +1,389 @@
+// Package shape trims the entities answered by the API to what a client asks for. The
+// references of an entity are the fields tagged `validate:"ref"` on its model, found by
+// reflection, so a new model gets expansion without any registration: ?expand=author,items.book
+// inlines the listed references and answers the others as their id, ?fields=id,title keeps
+// only the listed fields. Lists are also sorted with ?sort=-price,title and paginated with
+// ?limit=20&offset=40.
+package shape
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bookstore.com/repositories"
+)
+
+// Options is the shape of a response. Without Expand the references are answered as the
+// services return them. Sort lists the fields a list is sorted by, descending when prefixed
+// with -, Limit is the size of a page (0 for all the entities) from Offset.
+type Options struct {
+	Expand []string
+	Fields []string
+	Sort   []string
+	Limit  int
+	Offset int
+
+	expanding  bool
+	references []reference
+}
+
+// MaxLimit is the largest page a list may ask for
+const MaxLimit = 1000
+
+// reference is the JSON path of a reference in a model and the number of references crossed
+// to reach it
+type reference struct {
+	path  string
+	depth int
+}
+
+// MaxDepthFromEnv reads how many references an expansion may cross from EXPAND_MAX_DEPTH,
+// 2 by default
+func MaxDepthFromEnv() int {
+	depth, err := strconv.Atoi(os.Getenv("EXPAND_MAX_DEPTH"))
+	if err != nil || depth <= 0 {
+		return 2
+	}
+	return depth
+}
+
+// Parse reads the expand and fields query parameters for the responses holding the model,
+// an entity or a slice of entities. Unknown references or fields and expansions deeper than
+// maxDepth are invalid.
+func Parse(query url.Values, model interface{}, maxDepth int) (Options, error) {
+	t := entityType(reflect.TypeOf(model))
+	options := Options{references: references(t)}
+
+	if query.Has("expand") {
+		options.expanding = true
+		options.Expand = split(query.Get("expand"))
+		for _, path := range options.Expand {
+			i := slices.IndexFunc(options.references, func(ref reference) bool {
+				return ref.path == path
+			})
+			if i < 0 {
+				return Options{}, repositories.Invalid("invalid_expand", "cannot expand %s, the references are: %s", path, strings.Join(options.paths(), ", "))
+			}
+			if options.references[i].depth > maxDepth {
+				return Options{}, repositories.Invalid("invalid_expand", "cannot expand %s, the maximum expansion depth is %d", path, maxDepth)
+			}
+		}
+	}
+
+	if query.Has("fields") {
+		options.Fields = split(query.Get("fields"))
+		names := jsonNames(t)
+		for _, field := range options.Fields {
+			if !slices.Contains(names, field) {
+				return Options{}, repositories.Invalid("invalid_fields", "unknown field %s, the fields are: %s", field, strings.Join(names, ", "))
+			}
+		}
+	}
+
+	if query.Has("sort") {
+		options.Sort = split(query.Get("sort"))
+		names := jsonNames(t)
+		for _, field := range options.Sort {
+			if !slices.Contains(names, strings.TrimPrefix(field, "-")) {
+				return Options{}, repositories.Invalid("invalid_sort", "cannot sort by %s, the fields are: %s", field, strings.Join(names, ", "))
+			}
+		}
+	}
+
+	var err error
+	options.Limit, err = pageParameter(query, "limit")
+	if err != nil || options.Limit > MaxLimit || (query.Has("limit") && options.Limit == 0) {
+		return Options{}, repositories.Invalid("invalid_page", "limit must be a number between 1 and %d", MaxLimit)
+	}
+	if options.Offset, err = pageParameter(query, "offset"); err != nil {
+		return Options{}, repositories.Invalid("invalid_page", "offset must be a positive number")
+	}
+	return options, nil
+}
+
+func pageParameter(query url.Values, name string) (int, error) {
+	if !query.Has(name) {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(query.Get(name))
+	if err != nil || value < 0 {
+		return 0, repositories.ErrValidation
+	}
+	return value, nil
+}
+
+// Paginated tells whether the list is cut into pages
+func (o Options) Paginated() bool {
+	return o.Limit > 0 || o.Offset > 0
+}
+
+// Apply returns the entity or the slice of entities v in the shape of the options. A
+// paginated list is sorted by id after the Sort fields so the pages do not overlap.
+func (o Options) Apply(v interface{}) (interface{}, error) {
+	if !o.expanding && o.Fields == nil && o.Sort == nil && !o.Paginated() {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	if entities, ok := generic.([]interface{}); ok {
+		entities = o.page(entities)
+		for i := range entities {
+			entities[i] = o.entity(entities[i])
+		}
+		return entities, nil
+	}
+	return o.entity(generic), nil
+}
+
+// page sorts a list and returns the requested page
+func (o Options) page(entities []interface{}) []interface{} {
+	keys := o.Sort
+	if o.Paginated() {
+		keys = append(slices.Clone(keys), "id")
+	}
+	if len(keys) > 0 {
+		sort.SliceStable(entities, func(i, j int) bool {
+			a, _ := entities[i].(map[string]interface{})
+			b, _ := entities[j].(map[string]interface{})
+			for _, key := range keys {
+				field := strings.TrimPrefix(key, "-")
+				order := compare(a[field], b[field])
+				if strings.HasPrefix(key, "-") {
+					order = -order
+				}
+				if order != 0 {
+					return order < 0
+				}
+			}
+			return false
+		})
+	}
+	if !o.Paginated() {
+		return entities
+	}
+	if o.Offset >= len(entities) {
+		return []interface{}{}
+	}
+	entities = entities[o.Offset:]
+	if o.Limit > 0 && o.Limit < len(entities) {
+		entities = entities[:o.Limit]
+	}
+	return entities
+}
+
+// compare orders two JSON values of a field: numbers, strings (dates included, in RFC 3339)
+// and booleans, the missing values first
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case json.Number:
+		if b, ok := b.(json.Number); ok {
+			x, _ := a.Float64()
+			y, _ := b.Float64()
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0
+			case !a:
+				return -1
+			}
+			return 1
+		}
+	case nil:
+		if b == nil {
+			return 0
+		}
+		return -1
+	}
+	if b == nil {
+		return 1
+	}
+	return 0
+}
+
+func (o Options) entity(v interface{}) interface{} {
+	entity, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	if o.Fields != nil {
+		for name := range entity {
+			if !slices.Contains(o.Fields, name) {
+				delete(entity, name)
+			}
+		}
+	}
+	if o.expanding {
+		o.collapse(entity, "")
+	}
+	return entity
+}
+
+// collapse replaces the references of an object that are not expanded with their id
+func (o Options) collapse(object map[string]interface{}, prefix string) {
+	for name, value := range object {
+		path := prefix + name
+		if !o.contains(path) {
+			continue
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			object[name] = o.collapseValue(value, path)
+		case []interface{}:
+			for i, element := range value {
+				if element, ok := element.(map[string]interface{}); ok {
+					value[i] = o.collapseValue(element, path)
+				}
+			}
+		}
+	}
+}
+
+func (o Options) collapseValue(value map[string]interface{}, path string) map[string]interface{} {
+	if o.isReference(path) && !o.expanded(path) {
+		return map[string]interface{}{"id": value["id"]}
+	}
+	o.collapse(value, path+".")
+	return value
+}
+
+// contains tells whether a reference is at or below path
+func (o Options) contains(path string) bool {
+	return slices.ContainsFunc(o.references, func(ref reference) bool {
+		return ref.path == path || strings.HasPrefix(ref.path, path+".")
+	})
+}
+
+func (o Options) isReference(path string) bool {
+	return slices.ContainsFunc(o.references, func(ref reference) bool {
+		return ref.path == path
+	})
+}
+
+// expanded tells whether path or a reference below it is expanded
+func (o Options) expanded(path string) bool {
+	return slices.ContainsFunc(o.Expand, func(expand string) bool {
+		return expand == path || strings.HasPrefix(expand, path+".")
+	})
+}
+
+func (o Options) paths() []string {
+	paths := make([]string, len(o.references))
+	for i, ref := range o.references {
+		paths[i] = ref.path
+	}
+	return paths
+}
+
+func split(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// entityType returns the struct type of an entity, a pointer or a slice of entities
+func entityType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonNames returns the names of the JSON fields of a struct
+func jsonNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := jsonName(t.Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+	return name, true
+}
+
+// maxReferenceDepth stops the search for references in models referencing each other
+const maxReferenceDepth = 5
+
+var cache sync.Map
+
+// references lists the references of a model: its fields tagged ref and the references of
+// the entities they reference or of its nested entities tagged dive, sorted by path
+func references(t reflect.Type) []reference {
+	if cached, ok := cache.Load(t); ok {
+		return cached.([]reference)
+	}
+	var refs []reference
+	var walk func(t reflect.Type, prefix string, depth int)
+	walk = func(t reflect.Type, prefix string, depth int) {
+		if t.Kind() != reflect.Struct || depth >= maxReferenceDepth {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonName(field)
+			if !ok {
+				continue
+			}
+			rules := strings.Split(field.Tag.Get("validate"), ",")
+			switch {
+			case slices.Contains(rules, "ref"):
+				refs = append(refs, reference{path: prefix + name, depth: depth + 1})
+				walk(entityType(field.Type), prefix+name+".", depth+1)
+			case slices.Contains(rules, "dive"):
+				walk(entityType(field.Type), prefix+name+".", depth)
+			}
+		}
+	}
+	walk(t, "", 0)
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].path < refs[j].path
+	})
+	cache.Store(t, refs)
+	return refs
+}
@@ -0,0 +1,35 @@
+package shipping
+
+import (
+	"os"
+	"time"
+
+	"bookstore.com/models"
+)
+
+// Label is the shipping label bought from a carrier for a shipment
+type Label struct {
+	TrackingNumber string  `json:"tracking_number"`
+	LabelURL       string  `json:"label_url"`
+	Cost           float64 `json:"cost"`
+}
+
+// CarrierAdapter connects a carrier: it buys the labels of the shipments and
+// reports their tracking events
+type CarrierAdapter interface {
+	Name() string
+	CreateLabel(shipment models.Shipment, from, to models.Address) (Label, error)
+	// Track returns every tracking event of a parcel, the oldest first
+	Track(trackingNumber string) ([]models.ShipmentEvent, error)
+}
+
+// NewCarrierFromEnv builds the carrier of the shipments. Only the local fake carrier is
+// implemented, its parcels move to the next status every SHIPPING_FAKE_STEP (a duration,
+// 1 minute by default).
+func NewCarrierFromEnv() CarrierAdapter {
+	step, err := time.ParseDuration(os.Getenv("SHIPPING_FAKE_STEP"))
+	if err != nil || step <= 0 {
+		step = time.Minute
+	}
+	return NewFakeCarrier(step)
+}
@@ -0,0 +1,94 @@
+package shipping
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"bookstore.com/models"
+)
+
+// fakeProgress is the path of every parcel handled by the fake carrier
+var fakeProgress = []string{
+	models.ShipmentLabelCreated,
+	models.ShipmentInTransit,
+	models.ShipmentOutForDelivery,
+	models.ShipmentDelivered,
+}
+
+// FakeCarrier is a local carrier for development and tests. Its parcels move to the next
+// status every Step after the label is created, Now can be replaced to control the time.
+type FakeCarrier struct {
+	Step time.Duration
+	Now  func() time.Time
+
+	mu      sync.Mutex
+	nextID  int
+	parcels map[string]fakeParcel
+}
+
+type fakeParcel struct {
+	createdAt   time.Time
+	destination string
+}
+
+func NewFakeCarrier(step time.Duration) *FakeCarrier {
+	return &FakeCarrier{
+		Step:    step,
+		Now:     time.Now,
+		nextID:  1,
+		parcels: make(map[string]fakeParcel),
+	}
+}
+
+func (c *FakeCarrier) Name() string {
+	return "fake"
+}
+
+func (c *FakeCarrier) CreateLabel(shipment models.Shipment, from, to models.Address) (Label, error) {
+	if len(shipment.Packages) == 0 {
+		return Label{}, errors.New("a shipment needs at least one package")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	trackingNumber := fmt.Sprintf("FAKE%010d", c.nextID)
+	c.nextID++
+	c.parcels[trackingNumber] = fakeParcel{createdAt: c.Now(), destination: to.City}
+	return Label{
+		TrackingNumber: trackingNumber,
+		LabelURL:       "https://carrier.invalid/labels/" + trackingNumber,
+		Cost:           float64(len(shipment.Packages)) * 5,
+	}, nil
+}
+
+func (c *FakeCarrier) Track(trackingNumber string) ([]models.ShipmentEvent, error) {
+	c.mu.Lock()
+	parcel, exists := c.parcels[trackingNumber]
+	c.mu.Unlock()
+	if !exists {
+		return nil, errors.New("unknown tracking number " + trackingNumber)
+	}
+
+	now := c.Now()
+	var events []models.ShipmentEvent
+	for i, status := range fakeProgress {
+		occurredAt := parcel.createdAt.Add(time.Duration(i) * c.Step)
+		if occurredAt.After(now) {
+			break
+		}
+		location := "origin facility"
+		if status == models.ShipmentOutForDelivery || status == models.ShipmentDelivered {
+			location = parcel.destination
+		}
+		events = append(events, models.ShipmentEvent{
+			Status:      status,
+			Description: "Parcel " + status,
+			Location:    location,
+			OccurredAt:  occurredAt,
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,41 @@
+package validation
+
+import (
+	"strconv"
+	"strings"
+
+	"bookstore.com/models"
+)
+
+// the cross-field rules of the models, their single-field rules are in their tags
+func init() {
+	Register(func(book models.Book) Errors {
+		if book.ReorderTarget != 0 && book.ReorderTarget < book.ReorderThreshold {
+			return Errors{{Field: "reorder_target", Message: "must be at least reorder_threshold"}}
+		}
+		return nil
+	})
+	Register(func(author models.Author) Errors {
+		if strings.TrimSpace(author.FirstName) == "" && strings.TrimSpace(author.LastName) == "" {
+			return Errors{{Field: "last_name", Message: "is required when first_name is empty"}}
+		}
+		return nil
+	})
+	Register(func(line models.PurchaseOrderLine) Errors {
+		if line.Received > line.Quantity {
+			return Errors{{Field: "received", Message: "must not exceed quantity"}}
+		}
+		return nil
+	})
+	Register(func(order models.Order) Errors {
+		var errs Errors
+		seen := make(map[int]bool)
+		for i, item := range order.Items {
+			if item.Book.ID > 0 && seen[item.Book.ID] {
+				errs = append(errs, Violation{Field: "items[" + strconv.Itoa(i) + "].book", Message: "is already ordered by another item"})
+			}
+			seen[item.Book.ID] = true
+		}
+		return errs
+	})
+}
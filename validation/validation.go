@@ -0,0 +1,202 @@
+// Package validation checks the models against the rules of their `validate` struct tags and
+// the cross-field rules registered for their types. Violations are reported with the JSON path
+// of the field, e.g. "items[0].quantity".
+//
+// Tag rules, separated by commas:
+//
+//	required    the field is not empty (blank strings are empty)
+//	min=N       numbers are >= N, strings and lists have at least N characters or elements
+//	max=N       numbers are <= N, strings and lists have at most N characters or elements
+//	gt=N        numbers are > N
+//	email       a string is an email address, when not empty
+//	oneof=a b   a string is one of the values, when not empty
+//	ref         an embedded entity references another one by a positive id
+//	dive        the rules of a nested struct, or of each element of a list, are checked too
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Violation is a rule a field breaks
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors lists every violation of a value
+type Errors []Violation
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, violation := range e {
+		messages[i] = violation.Field + ": " + violation.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+var (
+	mu    sync.RWMutex
+	rules = make(map[reflect.Type][]func(reflect.Value) Errors)
+)
+
+// Register adds a cross-field rule to a type, it runs wherever a value of the type is
+// validated and the fields of its violations are relative to the value
+func Register[T any](rule func(T) Errors) {
+	mu.Lock()
+	defer mu.Unlock()
+	t := reflect.TypeFor[T]()
+	rules[t] = append(rules[t], func(v reflect.Value) Errors {
+		return rule(v.Interface().(T))
+	})
+}
+
+// Struct validates a struct, or a pointer to one, and returns its Errors or nil
+func Struct(value interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs Errors
+	validateStruct(v, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateStruct(v reflect.Value, path string, errs *Errors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("validate")
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		validateField(v.Field(i), join(path, jsonName(field)), strings.Split(tag, ","), errs)
+	}
+
+	mu.RLock()
+	typeRules := rules[t]
+	mu.RUnlock()
+	for _, rule := range typeRules {
+		for _, violation := range rule(v) {
+			violation.Field = join(path, violation.Field)
+			*errs = append(*errs, violation)
+		}
+	}
+}
+
+func validateField(v reflect.Value, path string, tagRules []string, errs *Errors) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, Violation{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, rule := range tagRules {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") ||
+				(v.Kind() == reflect.Slice && v.Len() == 0) {
+				fail("is required")
+				return
+			}
+		case "min", "max", "gt":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic("validation: invalid " + name + " rule " + strconv.Quote(rule))
+			}
+			checkLimit(v, name, limit, fail)
+		case "email":
+			if s := v.String(); s != "" {
+				if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+					fail("must be a valid email address")
+				}
+			}
+		case "oneof":
+			if s := v.String(); s != "" && !slices.Contains(strings.Fields(arg), s) {
+				fail("must be one of %s", strings.Join(strings.Fields(arg), ", "))
+			}
+		case "ref":
+			if id := v.FieldByName("ID"); !id.IsValid() || id.Int() <= 0 {
+				fail("must reference an entity by a positive id")
+			}
+		case "dive":
+			switch v.Kind() {
+			case reflect.Struct:
+				validateStruct(v, path, errs)
+			case reflect.Slice:
+				for i := 0; i < v.Len(); i++ {
+					if element := reflect.Indirect(v.Index(i)); element.Kind() == reflect.Struct {
+						validateStruct(element, path+"["+strconv.Itoa(i)+"]", errs)
+					}
+				}
+			}
+		default:
+			panic("validation: unknown rule " + strconv.Quote(rule))
+		}
+	}
+}
+
+func checkLimit(v reflect.Value, rule string, limit float64, fail func(string, ...interface{})) {
+	var value float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+	case reflect.String:
+		value, unit = float64(utf8.RuneCountInString(v.String())), " character"
+	case reflect.Slice, reflect.Map:
+		value, unit = float64(v.Len()), " element"
+	default:
+		return
+	}
+	bound := strconv.FormatFloat(limit, 'f', -1, 64)
+	if unit != "" && limit != 1 {
+		unit += "s"
+	}
+	switch {
+	case rule == "min" && value < limit:
+		if unit != "" {
+			fail("must have at least %s%s", bound, unit)
+		} else {
+			fail("must be at least %s", bound)
+		}
+	case rule == "max" && value > limit:
+		if unit != "" {
+			fail("must have at most %s%s", bound, unit)
+		} else {
+			fail("must be at most %s", bound)
+		}
+	case rule == "gt" && value <= limit:
+		fail("must be greater than %s", bound)
+	}
+}
+
+// jsonName returns the name of a field in the JSON documents
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func join(path, field string) string {
+	switch {
+	case path == "":
+		return field
+	case field == "":
+		return path
+	default:
+		return path + "." + field
+	}
+}